@@ -3,10 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"ghost/application"
+	"ghost/hosts"
 	"ghost/models"
-	"ghost/remote"
 )
 
 // App struct
@@ -33,6 +34,12 @@ func NewApp() *App {
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 
+	// 启动远程组刷新和本地文件检查的调度
+	err := a.hostApp.StartScheduler()
+	if err != nil {
+		fmt.Printf("Warning: failed to start refresh scheduler: %v\n", err)
+	}
+
 	// 检查备份目录是否为空，如果为空则备份当前系统hosts文件
 	isEmpty, err := a.IsBackupDirEmpty()
 	if err != nil {
@@ -53,6 +60,9 @@ func (a *App) startup(ctx context.Context) {
 
 // shutdown is called when the app is closing
 func (a *App) shutdown(ctx context.Context) {
+	// 停止调度并等待正在执行的刷新完成
+	a.hostApp.StopScheduler()
+
 	// 创建系统hosts文件的备份
 	err := a.hostApp.BackupConfig()
 	if err != nil {
@@ -87,11 +97,96 @@ func (a *App) ToggleHostGroup(id string, enabled bool) error {
 	return a.hostApp.ToggleHostGroup(id, enabled)
 }
 
+// EnableHostGroupFor 临时启用Host分组，并在指定秒数后自动禁用
+func (a *App) EnableHostGroupFor(id string, seconds int64) error {
+	return a.hostApp.EnableHostGroupFor(id, time.Duration(seconds)*time.Second)
+}
+
+// ListProfiles 获取所有配置方案
+func (a *App) ListProfiles() ([]models.Profile, error) {
+	return a.hostApp.ListProfiles()
+}
+
+// CreateProfile 添加新的配置方案
+func (a *App) CreateProfile(profile models.Profile) error {
+	return a.hostApp.CreateProfile(profile)
+}
+
+// UpdateProfile 更新配置方案
+func (a *App) UpdateProfile(profile models.Profile) error {
+	return a.hostApp.UpdateProfile(profile)
+}
+
+// DeleteProfile 删除配置方案
+func (a *App) DeleteProfile(id string) error {
+	return a.hostApp.DeleteProfile(id)
+}
+
+// SwitchProfile 切换到指定名称的配置方案并应用
+func (a *App) SwitchProfile(name string) error {
+	return a.hostApp.SwitchProfile(name)
+}
+
+// SwitchProfileWithResolution 切换配置方案，并指定检测到Ghost段被手动修改时的处理方式（import、overwrite、abort）
+func (a *App) SwitchProfileWithResolution(name string, resolution string) error {
+	return a.hostApp.SwitchProfileWithResolution(name, resolution)
+}
+
+// ExportProfiles 将所有配置方案导出为JSON
+func (a *App) ExportProfiles() (string, error) {
+	return a.hostApp.ExportProfiles()
+}
+
+// ImportProfiles 从JSON导入配置方案，返回导入的数量
+func (a *App) ImportProfiles(data string) (int, error) {
+	return a.hostApp.ImportProfiles(data)
+}
+
+// ApplyHostsWithResolution 应用Host分组，并指定检测到Ghost段被手动修改时的处理方式（import、overwrite、abort）
+func (a *App) ApplyHostsWithResolution(resolution string) error {
+	return a.hostApp.ApplyHostsWithResolution(resolution)
+}
+
+// CheckDrift 检查Ghost段是否在上次写入后被手动修改
+func (a *App) CheckDrift() (*models.DriftReport, error) {
+	return a.hostApp.CheckDrift()
+}
+
+// ReorderHostGroups 按照给定的ID顺序调整Host分组优先级
+func (a *App) ReorderHostGroups(ids []string) error {
+	return a.hostApp.ReorderHostGroups(ids)
+}
+
 // ApplyHosts 应用所有启用的Host分组到系统
 func (a *App) ApplyHosts() error {
 	return a.hostApp.ApplyHosts()
 }
 
+// DetectConflicts 检测启用的分组之间的主机名冲突
+func (a *App) DetectConflicts() ([]hosts.Conflict, error) {
+	return a.hostApp.DetectConflicts()
+}
+
+// PreviewApply 预览应用Host分组后系统hosts文件的变化
+func (a *App) PreviewApply() (*models.ApplyPreview, error) {
+	return a.hostApp.PreviewApply()
+}
+
+// ListApplyHistory 列出应用历史记录
+func (a *App) ListApplyHistory() ([]models.ApplyRecord, error) {
+	return a.hostApp.ListApplyHistory()
+}
+
+// GetApplySnapshot 获取指定应用历史记录的完整快照
+func (a *App) GetApplySnapshot(id string) (*models.ApplySnapshot, error) {
+	return a.hostApp.GetApplySnapshot(id)
+}
+
+// RollbackToApply 将系统hosts文件回滚到指定应用之前的状态
+func (a *App) RollbackToApply(id string) error {
+	return a.hostApp.RollbackToApply(id)
+}
+
 // GetSystemHostPath 获取系统hosts文件路径
 func (a *App) GetSystemHostPath() string {
 	return a.hostApp.GetSystemHostPath()
@@ -102,6 +197,16 @@ func (a *App) GetSystemHostsContent() (string, error) {
 	return a.hostApp.GetSystemHostsContent()
 }
 
+// GetSystemHostsOrigins 获取系统hosts文件每一行的来源分组
+func (a *App) GetSystemHostsOrigins() ([]models.LineOrigin, error) {
+	return a.hostApp.GetSystemHostsOrigins()
+}
+
+// ImportGroupsFromSystemHosts 从系统hosts文件的Ghost段导入分组
+func (a *App) ImportGroupsFromSystemHosts() (int, error) {
+	return a.hostApp.ImportGroupsFromSystemHosts()
+}
+
 // RefreshRemoteGroups 刷新所有远程Host组
 func (a *App) RefreshRemoteGroups() error {
 	return a.hostApp.RefreshRemoteGroups()
@@ -122,10 +227,14 @@ func (a *App) GetHostGroup(id string) (*models.HostGroup, error) {
 	return a.hostApp.GetHostGroup(id)
 }
 
+// GetHostGroupEntries 获取指定Host分组解析后的条目
+func (a *App) GetHostGroupEntries(id string) ([]hosts.Entry, error) {
+	return a.hostApp.GetHostGroupEntries(id)
+}
+
 // GetRemoteContent 获取指定URL的远程hosts内容
 func (a *App) GetRemoteContent(url string) (string, error) {
-	remoteFetcher := remote.NewRemoteFetcher()
-	content, err := remoteFetcher.FetchRemoteHosts(url)
+	content, err := a.hostApp.FetchRemoteContent(url)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch remote content: %w", err)
 	}
@@ -172,6 +281,41 @@ func (a *App) RestoreData(backupFileName string) error {
 	return a.hostApp.RestoreData(backupFileName)
 }
 
+// GetAutoApplyStatus 获取自动应用的最近状态
+func (a *App) GetAutoApplyStatus() models.AutoApplyStatus {
+	return a.hostApp.GetAutoApplyStatus()
+}
+
+// ListGroupChanges 列出指定分组的内容变化记录（最新的在前）
+func (a *App) ListGroupChanges(id string) ([]models.GroupChange, error) {
+	return a.hostApp.ListGroupChanges(id)
+}
+
+// ListSwitchLog 列出分组的自动切换记录（最新的在前）
+func (a *App) ListSwitchLog() ([]models.SwitchRecord, error) {
+	return a.hostApp.ListSwitchLog()
+}
+
+// SetGroupCredentials 设置远程分组的认证信息
+func (a *App) SetGroupCredentials(id string, creds models.RemoteCredentials) error {
+	return a.hostApp.SetGroupCredentials(id, creds)
+}
+
+// GetGroupCredentials 获取远程分组的认证信息（敏感字段已隐藏）
+func (a *App) GetGroupCredentials(id string) (*models.RemoteCredentials, error) {
+	return a.hostApp.GetGroupCredentials(id)
+}
+
+// DeleteGroupCredentials 删除远程分组的认证信息
+func (a *App) DeleteGroupCredentials(id string) error {
+	return a.hostApp.DeleteGroupCredentials(id)
+}
+
+// GetStorageRecoveries 获取数据文件的损坏恢复记录
+func (a *App) GetStorageRecoveries() []models.RecoveryEvent {
+	return a.hostApp.GetStorageRecoveries()
+}
+
 // HasRawHostsBackup 检查是否存在原始hosts备份文件
 func (a *App) HasRawHostsBackup() (bool, error) {
 	return a.hostApp.HasRawHostsBackup()
@@ -192,6 +336,11 @@ func (a *App) RestoreRawSystemHosts(backupFileName string) error {
 	return a.hostApp.RestoreRawSystemHosts(backupFileName)
 }
 
+// GetSchedule 获取远程组刷新和本地文件检查的调度计划
+func (a *App) GetSchedule() []models.ScheduleEntry {
+	return a.hostApp.GetSchedule()
+}
+
 // StartRemoteGroupRefreshTimer 启动指定远程组的定时刷新
 func (a *App) StartRemoteGroupRefreshTimer(id string) error {
 	return a.hostApp.StartRemoteGroupRefreshTimer(id)
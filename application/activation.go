@@ -0,0 +1,134 @@
+package application
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ghost/models"
+)
+
+// activationRetryDelay 处理启用计划失败后重试的等待时间
+const activationRetryDelay = time.Minute
+
+// ListSwitchLog 列出分组的自动切换记录（最新的在前）
+func (app *HostApp) ListSwitchLog() ([]models.SwitchRecord, error) {
+	return app.configStorage.ListSwitchLog()
+}
+
+// activationItemFor 为设置了启用计划的分组生成调度项
+// 加入调度后立即检查一次，补做应用关闭期间错过的切换
+func activationItemFor(group *models.HostGroup) (*scheduleItem, bool) {
+	if group.Activation == nil {
+		return nil, false
+	}
+	return &scheduleItem{
+		groupID: group.ID,
+		name:    group.Name,
+		kind:    scheduleKindActivation,
+		due:     wallClock(),
+	}, true
+}
+
+// runActivation 按启用计划切换分组，返回下一次需要检查的时间
+func (app *HostApp) runActivation(id string) time.Time {
+	now := wallClock()
+	next, err := app.applyActivation(id, now)
+	if err != nil {
+		log.Printf("Error applying activation schedule for group %s: %v", id, err)
+		return now.Add(activationRetryDelay)
+	}
+	return next
+}
+
+// applyActivation 处理最近一次尚未处理的计划切换
+// 每个切换时间点只处理一次，两次切换之间手动启用或禁用分组不会被覆盖
+func (app *HostApp) applyActivation(id string, now time.Time) (time.Time, error) {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	var group *models.HostGroup
+	for i := range manager.Groups {
+		if manager.Groups[i].ID == id {
+			group = &manager.Groups[i]
+			break
+		}
+	}
+	if group == nil {
+		return time.Time{}, fmt.Errorf("host group with ID %s not found", id)
+	}
+	if group.Activation == nil {
+		return time.Time{}, fmt.Errorf("host group %s has no activation schedule", group.Name)
+	}
+
+	next, err := group.Activation.NextEvent(now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	event, err := group.Activation.LastEvent(now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if event == nil {
+		return next, nil
+	}
+	handled, err := time.Parse(time.RFC3339, group.ActivationAt)
+	if err == nil && !event.At.After(handled) {
+		return next, nil
+	}
+
+	group.ActivationAt = event.At.Format(time.RFC3339)
+	switched := group.Enabled != event.Enabled
+	if switched {
+		group.Enabled = event.Enabled
+		group.ExpiresAt = ""
+		group.UpdatedAt = now.Format(time.RFC3339)
+	}
+	manager.UpdatedAt = now.Format(time.RFC3339)
+
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to save host manager: %w", err)
+	}
+	if !switched {
+		return next, nil
+	}
+
+	log.Printf("Group %s switched to enabled=%t by activation schedule: %s", group.Name, group.Enabled, event.Reason)
+	app.recordSwitch(group, models.SwitchTriggerSchedule, event.Reason)
+	app.rescheduleGroup(id)
+
+	// 切换后的状态需要写入系统hosts文件才会生效，同一时间点的多个切换合并为一次写入
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		config = &models.AppConfig{}
+	}
+	app.scheduleAutoApply(id, autoApplyDelay(config))
+
+	return next, nil
+}
+
+// recordSwitch 保存一条自动切换记录，失败只记录日志
+func (app *HostApp) recordSwitch(group *models.HostGroup, trigger, reason string) {
+	record := &models.SwitchRecord{
+		ID:        uuid.New().String(),
+		GroupID:   group.ID,
+		GroupName: group.Name,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Enabled:   group.Enabled,
+		Trigger:   trigger,
+		Reason:    reason,
+	}
+
+	err := app.configStorage.SaveSwitchRecord(record)
+	if err != nil {
+		log.Printf("Warning: failed to save switch record for group %s: %v", group.ID, err)
+	}
+}
@@ -0,0 +1,110 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"ghost/models"
+)
+
+// switchRecords 返回保存的切换记录（最新的在前）
+func switchRecords(t *testing.T, app *HostApp) []models.SwitchRecord {
+	t.Helper()
+	records, err := app.ListSwitchLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+// TestExpireGroup 测试到期前不做任何事，到期后禁用分组、写入切换记录并安排自动应用
+func TestExpireGroup(t *testing.T) {
+	app, _ := newTestApp(t)
+	id := addTestGroup(t, app, models.HostGroup{Name: "Dev", Content: "10.0.0.1 api.local"})
+	if err := app.EnableHostGroupFor(id, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, findTestGroup(t, app, id).ExpiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := app.expireGroup(id, expiresAt.Add(-time.Minute))
+	if err != nil || !next.Equal(expiresAt) {
+		t.Errorf("expected next check at %s before expiry, got %s (%v)", expiresAt, next, err)
+	}
+	if !findTestGroup(t, app, id).Enabled || len(switchRecords(t, app)) != 0 {
+		t.Error("expected nothing to change before expiry")
+	}
+
+	if _, err := app.expireGroup(id, expiresAt.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	group := findTestGroup(t, app, id)
+	if group.Enabled || group.ExpiresAt != "" {
+		t.Errorf("expected group disabled without expiry, got %+v", group)
+	}
+	records := switchRecords(t, app)
+	if len(records) != 1 || records[0].Trigger != models.SwitchTriggerExpiry || records[0].Enabled || records[0].GroupID != id {
+		t.Errorf("unexpected switch log %+v", records)
+	}
+	if status := app.GetAutoApplyStatus(); len(status.Pending) != 1 || status.Pending[0] != id {
+		t.Errorf("expected auto apply to be scheduled for %s, got %+v", id, status)
+	}
+	if _, ok := app.scheduler.nextRun(scheduleKey(scheduleKindExpiry, id)); ok {
+		t.Error("expected expiry job to be removed after expiring")
+	}
+}
+
+// TestApplyActivation 测试每个计划切换只处理一次，两次切换之间的手动修改不会被覆盖
+func TestApplyActivation(t *testing.T) {
+	app, _ := newTestApp(t)
+	schedule := &models.ActivationSchedule{
+		Timezone: "UTC",
+		Rules:    []models.ActivationRule{{Cron: "0 9 * * *", Action: models.ActivationEnable}, {Cron: "0 18 * * *", Action: models.ActivationDisable}},
+	}
+	id := addTestGroup(t, app, models.HostGroup{Name: "Work", Content: "10.0.0.1 api.local", Activation: schedule})
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		at       time.Duration // 距当天零点的时间
+		manual   *bool         // 检查前手动设置的启用状态
+		enabled  bool
+		switches int
+		next     time.Duration
+	}{
+		{name: "missed enable", at: 12 * time.Hour, enabled: true, switches: 1, next: 18 * time.Hour},
+		{name: "same event again", at: 13 * time.Hour, enabled: true, switches: 1, next: 18 * time.Hour},
+		{name: "manual disable kept", at: 14 * time.Hour, manual: new(bool), enabled: false, switches: 1, next: 18 * time.Hour},
+		{name: "disable while already disabled", at: 18*time.Hour + time.Minute, enabled: false, switches: 1, next: 33 * time.Hour},
+		{name: "next enable", at: 33 * time.Hour, enabled: true, switches: 2, next: 42 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if tt.manual != nil {
+			if err := app.ToggleHostGroup(id, *tt.manual); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		next, err := app.applyActivation(id, day.Add(tt.at))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !next.Equal(day.Add(tt.next)) {
+			t.Errorf("%s: expected next check at %s, got %s", tt.name, day.Add(tt.next), next)
+		}
+		if group := findTestGroup(t, app, id); group.Enabled != tt.enabled {
+			t.Errorf("%s: expected enabled=%t, got %t", tt.name, tt.enabled, group.Enabled)
+		}
+		records := switchRecords(t, app)
+		if len(records) != tt.switches {
+			t.Errorf("%s: expected %d switch records, got %+v", tt.name, tt.switches, records)
+			continue
+		}
+		if records[0].Trigger != models.SwitchTriggerSchedule || !records[0].Enabled {
+			t.Errorf("%s: unexpected latest switch record %+v", tt.name, records[0])
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package application
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ghost/models"
+)
+
+// ListApplyHistory 列出应用历史记录（最新的在前）
+func (app *HostApp) ListApplyHistory() ([]models.ApplyRecord, error) {
+	return app.configStorage.ListApplyHistory()
+}
+
+// GetApplySnapshot 获取指定应用历史记录的完整快照
+func (app *HostApp) GetApplySnapshot(id string) (*models.ApplySnapshot, error) {
+	return app.configStorage.LoadApplySnapshot(id)
+}
+
+// RollbackToApply 将系统hosts文件恢复到指定应用记录写入之前的状态
+// 回滚只恢复系统hosts文件，不修改分组的启用状态；回滚本身也会被记录到历史中
+func (app *HostApp) RollbackToApply(id string) error {
+	snapshot, err := app.configStorage.LoadApplySnapshot(id)
+	if err != nil {
+		return fmt.Errorf("failed to load apply history: %w", err)
+	}
+
+	err = app.ensureWritePermission()
+	if err != nil {
+		return err
+	}
+
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	currentContent, err := app.hostManager.ReadSystemHosts()
+	if err != nil {
+		return fmt.Errorf("failed to read current system hosts: %w", err)
+	}
+
+	err = app.hostManager.WriteSystemHosts(snapshot.Before)
+	if err != nil {
+		return fmt.Errorf("failed to roll back system hosts: %w", err)
+	}
+
+	log.Printf("Rolled back system hosts file to state before apply %s", id)
+
+	// 更新Ghost段摘要，避免回滚后的内容被误判为手动修改
+	manager, err := app.configStorage.LoadHostManager()
+	if err == nil {
+		manager.LastSectionHash = sectionHash(snapshot.Before)
+		err = app.configStorage.SaveHostManager(manager)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to update ghost section hash after rollback: %v", err)
+	}
+
+	// 回滚只恢复文件内容，不改变分组的启用状态，因此不记录启用的分组
+	app.recordApply(models.ApplyKindRollback, currentContent, snapshot.Before, []string{}, id)
+
+	return nil
+}
+
+// recordApply 保存一次写入系统hosts文件的快照，失败时只记录日志
+func (app *HostApp) recordApply(kind, before, after string, groupIDs []string, rollbackOf string) {
+	now := time.Now()
+	snapshot := &models.ApplySnapshot{
+		ApplyRecord: models.ApplyRecord{
+			// ID以时间戳开头，保证按字典序排列即为时间顺序
+			ID:              now.Format("20060102_150405.000") + "-" + uuid.New().String()[:8],
+			Kind:            kind,
+			Timestamp:       now.Format(time.RFC3339),
+			EnabledGroupIDs: groupIDs,
+			BeforeHash:      hashContent(before),
+			AfterHash:       hashContent(after),
+			RollbackOf:      rollbackOf,
+		},
+		Before: before,
+		After:  after,
+	}
+
+	err := app.configStorage.SaveApplySnapshot(snapshot)
+	if err != nil {
+		log.Printf("Warning: failed to record apply history: %v", err)
+	}
+}
+
+// enabledGroupIDs 返回所有启用分组的ID
+func enabledGroupIDs(groups []models.HostGroup) []string {
+	ids := []string{}
+	for _, group := range groups {
+		if group.Enabled {
+			ids = append(ids, group.ID)
+		}
+	}
+	return ids
+}
+
+// hashContent 计算内容的SHA-256摘要
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,57 @@
+package application
+
+import (
+	"os"
+	"testing"
+
+	"ghost/models"
+)
+
+// TestRollbackToApply 测试回滚到不存在的快照时不修改系统hosts文件，回滚成功时记录不含启用分组的回滚记录
+func TestRollbackToApply(t *testing.T) {
+	app, _ := newTestApp(t)
+	path := app.hostManager.HostsPath()
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.AddHostGroup(models.HostGroup{Name: "Dev", Enabled: true, Content: "10.0.0.1 api.local"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.applyStored(""); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := app.configStorage.ListApplyHistory()
+	if err != nil || len(history) != 1 {
+		t.Fatalf("expected one apply record, got %v (%v)", history, err)
+	}
+
+	if err := app.RollbackToApply("20000101_000000"); err == nil {
+		t.Error("expected rollback to a missing snapshot to fail")
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(applied) {
+		t.Errorf("failed rollback modified the system hosts file:\n%s", data)
+	}
+
+	if err := app.RollbackToApply(history[0].ID); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(original) {
+		t.Errorf("expected original content after rollback, got:\n%s", data)
+	}
+
+	history, err = app.configStorage.ListApplyHistory()
+	if err != nil || len(history) != 2 {
+		t.Fatalf("expected apply and rollback records, got %v (%v)", history, err)
+	}
+	rollback := history[0]
+	if rollback.Kind != models.ApplyKindRollback || rollback.RollbackOf != history[1].ID || len(rollback.EnabledGroupIDs) != 0 {
+		t.Errorf("unexpected rollback record: %+v", rollback)
+	}
+}
@@ -0,0 +1,134 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ghost/models"
+)
+
+// defaultAutoApplyDelay 未配置时自动应用前的等待时间
+const defaultAutoApplyDelay = 2 * time.Second
+
+// ErrPermissionRequired 后台应用时没有写入系统hosts文件的权限
+var ErrPermissionRequired = errors.New("permission required to write the system hosts file")
+
+// GetAutoApplyStatus 获取自动应用的最近状态
+func (app *HostApp) GetAutoApplyStatus() models.AutoApplyStatus {
+	app.autoApplyMu.Lock()
+	defer app.autoApplyMu.Unlock()
+
+	status := app.autoApplyStatus
+	status.Pending = make([]string, 0, len(app.autoApplyPending))
+	for id := range app.autoApplyPending {
+		status.Pending = append(status.Pending, id)
+	}
+	return status
+}
+
+// maybeAutoApply 分组内容变化后按照分组和全局设置决定是否安排自动应用
+func (app *HostApp) maybeAutoApply(group *models.HostGroup) {
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		log.Printf("Warning: failed to load config for auto apply: %v", err)
+		return
+	}
+	if !group.ShouldAutoApply(config) {
+		return
+	}
+
+	app.scheduleAutoApply(group.ID, autoApplyDelay(config))
+}
+
+// autoApplyDelay 返回自动应用前的等待时间
+func autoApplyDelay(config *models.AppConfig) time.Duration {
+	if config.AutoApplyDelay > 0 {
+		return time.Duration(config.AutoApplyDelay) * time.Second
+	}
+	return defaultAutoApplyDelay
+}
+
+// scheduleAutoApply 安排一次自动应用，等待期间的再次触发会推迟执行，多个分组的变化合并为一次写入
+func (app *HostApp) scheduleAutoApply(groupID string, delay time.Duration) {
+	app.autoApplyMu.Lock()
+	defer app.autoApplyMu.Unlock()
+
+	app.autoApplyPending[groupID] = true
+	app.autoApplyStatus.ScheduledAt = time.Now().Add(delay).Format(time.RFC3339)
+
+	if app.autoApplyTimer != nil {
+		app.autoApplyTimer.Reset(delay)
+		return
+	}
+	app.autoApplyTimer = time.AfterFunc(delay, app.runAutoApply)
+}
+
+// stopAutoApply 取消等待中的自动应用，用于关闭应用时避免在退出过程中写入系统文件
+func (app *HostApp) stopAutoApply() {
+	app.autoApplyMu.Lock()
+	defer app.autoApplyMu.Unlock()
+
+	if app.autoApplyTimer != nil {
+		app.autoApplyTimer.Stop()
+		app.autoApplyTimer = nil
+	}
+	app.autoApplyPending = make(map[string]bool)
+	app.autoApplyStatus.ScheduledAt = ""
+}
+
+// runAutoApply 执行自动应用，失败只记录状态和日志
+func (app *HostApp) runAutoApply() {
+	app.autoApplyMu.Lock()
+	groups := make([]string, 0, len(app.autoApplyPending))
+	for id := range app.autoApplyPending {
+		groups = append(groups, id)
+	}
+	app.autoApplyPending = make(map[string]bool)
+	app.autoApplyTimer = nil
+	app.autoApplyStatus.ScheduledAt = ""
+	app.autoApplyMu.Unlock()
+
+	err := app.applyRecovered()
+
+	app.autoApplyMu.Lock()
+	defer app.autoApplyMu.Unlock()
+
+	now := time.Now().Format(time.RFC3339)
+	app.autoApplyStatus.LastAttempt = now
+	app.autoApplyStatus.LastGroups = groups
+	if err != nil {
+		app.autoApplyStatus.LastError = err.Error()
+		log.Printf("Auto apply for %d changed groups failed: %v", len(groups), err)
+		return
+	}
+	app.autoApplyStatus.LastSuccess = now
+	app.autoApplyStatus.LastError = ""
+	log.Printf("Auto applied hosts after %d groups changed", len(groups))
+}
+
+// applyRecovered 在后台应用分组，并将panic转换为错误，避免影响后台goroutine
+// 后台应用不会请求提升权限（提升权限会重新启动应用），没有写入权限时直接返回ErrPermissionRequired
+func (app *HostApp) applyRecovered() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("apply panicked: %v", r)
+		}
+	}()
+
+	if !app.hostManager.HasWritePermission() {
+		return ErrPermissionRequired
+	}
+	return app.applyStored("")
+}
+
+// refreshRecovered 执行刷新，并将panic转换为错误，避免调度器的工作goroutine退出
+func refreshRecovered(refresh func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("refresh panicked: %v", r)
+		}
+	}()
+	return refresh()
+}
@@ -0,0 +1,95 @@
+package application
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"ghost/models"
+)
+
+// waitForAutoApply 等待自动应用执行完成，返回最近的状态
+func waitForAutoApply(t *testing.T, app *HostApp) models.AutoApplyStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := app.GetAutoApplyStatus(); status.LastAttempt != "" {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("auto apply did not run")
+	return models.AutoApplyStatus{}
+}
+
+// TestScheduleAutoApplyDebounce 测试等待期间的多次触发合并为一次写入
+func TestScheduleAutoApplyDebounce(t *testing.T) {
+	app, hostsFile := newTestApp(t)
+	addTestGroup(t, app, models.HostGroup{Name: "Dev", Enabled: true, Content: "10.0.0.1 api.local"})
+
+	for _, id := range []string{"a", "b", "a"} {
+		app.scheduleAutoApply(id, 100*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+	}
+	if status := app.GetAutoApplyStatus(); len(status.Pending) != 2 || status.ScheduledAt == "" {
+		t.Errorf("expected two pending groups and a scheduled time, got %+v", status)
+	}
+
+	status := waitForAutoApply(t, app)
+	time.Sleep(150 * time.Millisecond)
+
+	if writes, _ := hostsFile.counts(); writes != 1 {
+		t.Errorf("expected a single write, got %d", writes)
+	}
+	slices.Sort(status.LastGroups)
+	if !slices.Equal(status.LastGroups, []string{"a", "b"}) || status.LastError != "" || status.LastSuccess == "" {
+		t.Errorf("unexpected status after auto apply: %+v", status)
+	}
+	if status := app.GetAutoApplyStatus(); len(status.Pending) != 0 || status.ScheduledAt != "" {
+		t.Errorf("expected nothing pending, got %+v", status)
+	}
+}
+
+// TestStopAutoApply 测试关闭时取消等待中的自动应用
+func TestStopAutoApply(t *testing.T) {
+	app, hostsFile := newTestApp(t)
+
+	app.scheduleAutoApply("a", 50*time.Millisecond)
+	app.stopAutoApply()
+	time.Sleep(150 * time.Millisecond)
+
+	if writes, _ := hostsFile.counts(); writes != 0 {
+		t.Errorf("expected no write after stop, got %d", writes)
+	}
+	if status := app.GetAutoApplyStatus(); status.LastAttempt != "" || len(status.Pending) != 0 {
+		t.Errorf("expected auto apply to be cancelled, got %+v", status)
+	}
+}
+
+// TestAutoApplyNeverElevates 测试后台应用没有写入权限时直接失败，不会请求提升权限；界面触发的应用才会请求
+func TestAutoApplyNeverElevates(t *testing.T) {
+	app, hostsFile := newTestApp(t)
+	addTestGroup(t, app, models.HostGroup{Name: "Dev", Enabled: true, Content: "10.0.0.1 api.local"})
+	hostsFile.setWritable(false)
+
+	if err := app.applyRecovered(); !errors.Is(err, ErrPermissionRequired) {
+		t.Errorf("expected ErrPermissionRequired, got %v", err)
+	}
+
+	app.scheduleAutoApply("a", 10*time.Millisecond)
+	status := waitForAutoApply(t, app)
+	if status.LastError != ErrPermissionRequired.Error() || status.LastSuccess != "" {
+		t.Errorf("expected permission error in status, got %+v", status)
+	}
+	if writes, elevations := hostsFile.counts(); writes != 0 || elevations != 0 {
+		t.Errorf("expected no writes or elevation requests, got %d writes and %d elevations", writes, elevations)
+	}
+
+	if err := app.ApplyHosts(); !errors.Is(err, errElevationRequested) {
+		t.Errorf("expected foreground apply to request elevation, got %v", err)
+	}
+	if _, elevations := hostsFile.counts(); elevations != 1 {
+		t.Errorf("expected one elevation request from the foreground apply, got %d", elevations)
+	}
+}
@@ -0,0 +1,87 @@
+package application
+
+import (
+	"fmt"
+
+	"ghost/models"
+)
+
+// SetGroupCredentials 设置远程分组的认证信息
+// 仍为占位符的敏感字段沿用已保存的值，因此可以直接提交GetGroupCredentials返回的内容
+func (app *HostApp) SetGroupCredentials(id string, creds models.RemoteCredentials) error {
+	group, err := app.findRemoteGroup(id)
+	if err != nil {
+		return err
+	}
+
+	switch creds.AuthType {
+	case "":
+		creds.AuthType = models.AuthNone
+	case models.AuthNone, models.AuthBasic, models.AuthBearer:
+	default:
+		return fmt.Errorf("unsupported auth type: %s", creds.AuthType)
+	}
+
+	old, err := app.configStorage.LoadCredentials(group.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load credentials: %w", err)
+	}
+	creds.MergeMasked(old)
+
+	if (creds.ClientCert == "") != (creds.ClientKey == "") {
+		return fmt.Errorf("client certificate and key must be provided together")
+	}
+
+	err = app.configStorage.SaveCredentials(group.ID, &creds)
+	if err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+
+	return nil
+}
+
+// GetGroupCredentials 获取远程分组的认证信息，敏感字段以占位符代替，未设置时返回nil
+func (app *HostApp) GetGroupCredentials(id string) (*models.RemoteCredentials, error) {
+	group, err := app.findRemoteGroup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := app.configStorage.LoadCredentials(group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	if creds == nil {
+		return nil, nil
+	}
+
+	return creds.Masked(), nil
+}
+
+// DeleteGroupCredentials 删除远程分组的认证信息
+func (app *HostApp) DeleteGroupCredentials(id string) error {
+	err := app.configStorage.DeleteCredentials(id)
+	if err != nil {
+		return fmt.Errorf("failed to delete credentials: %w", err)
+	}
+	return nil
+}
+
+// findRemoteGroup 查找指定ID的远程分组
+func (app *HostApp) findRemoteGroup(id string) (*models.HostGroup, error) {
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	for i := range manager.Groups {
+		if manager.Groups[i].ID == id {
+			if !manager.Groups[i].IsRemote {
+				return nil, fmt.Errorf("host group %s is not a remote group", id)
+			}
+			return &manager.Groups[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("host group with ID %s not found", id)
+}
@@ -0,0 +1,115 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ghost/models"
+	"ghost/system"
+)
+
+// DriftError 表示Ghost段在上次写入后被手动修改，需要调用者选择处理方式
+type DriftError struct {
+	Report *models.DriftReport
+}
+
+// Error 实现error接口
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("ghost section was modified outside of Ghost (%d edited lines); choose import, overwrite or abort",
+		len(e.Report.EditedLines))
+}
+
+// CheckDrift 检查系统hosts文件中的Ghost段是否在上次写入后被手动修改
+func (app *HostApp) CheckDrift() (*models.DriftReport, error) {
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	config = manager.EffectiveConfig(config)
+
+	currentContent, err := app.hostManager.ReadSystemHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system hosts file: %w", err)
+	}
+
+	newContent, err := app.hostManager.RenderHostGroups(currentContent, buildHostGroups(manager.Groups), config.MergeMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render host groups: %w", err)
+	}
+
+	return detectDrift(manager, currentContent, newContent), nil
+}
+
+// detectDrift 比较当前Ghost段与上次写入时的摘要
+// EditedLines为当前Ghost段中既不会出现在新内容里、也不属于任何启用分组的行，即应用时会丢失的手动修改
+// 属于已禁用分组的行也算作手动修改，用户可能是在手动重新启用这些条目
+func detectDrift(manager *models.HostManager, currentContent, newContent string) *models.DriftReport {
+	report := &models.DriftReport{
+		ExpectedHash: manager.LastSectionHash,
+		CurrentHash:  sectionHash(currentContent),
+		EditedLines:  []string{},
+	}
+
+	// 从未写入过或Ghost段已被整体删除时，不会丢失任何修改
+	if report.ExpectedHash == "" || report.CurrentHash == "" || report.ExpectedHash == report.CurrentHash {
+		return report
+	}
+	report.Drifted = true
+
+	known := make(map[string]bool)
+	newSection, _ := system.ExtractGhostSection(newContent)
+	for _, line := range strings.Split(newSection, "\n") {
+		known[strings.TrimSpace(line)] = true
+	}
+	for _, group := range manager.Groups {
+		if !group.Enabled {
+			continue
+		}
+		for _, line := range strings.Split(group.Content, "\n") {
+			known[strings.TrimSpace(line)] = true
+		}
+	}
+
+	currentSection, _ := system.ExtractGhostSection(currentContent)
+	for _, line := range strings.Split(currentSection, "\n") {
+		text := strings.TrimSpace(line)
+		if text == "" || known[text] || system.IsGeneratedLine(line) {
+			continue
+		}
+		report.EditedLines = append(report.EditedLines, text)
+	}
+
+	return report
+}
+
+// newImportedGroup 使用手动修改的行创建一个新的本地分组
+func (app *HostApp) newImportedGroup(lines []string) models.HostGroup {
+	now := time.Now()
+	return models.HostGroup{
+		ID:          uuid.New().String(),
+		Name:        "Imported edits " + now.Format("2006-01-02 15:04:05"),
+		Description: "Lines edited manually in the Ghost section of the system hosts file",
+		Content:     strings.Join(lines, "\n"),
+		Enabled:     true,
+		CreatedAt:   now.Format(time.RFC3339),
+		UpdatedAt:   now.Format(time.RFC3339),
+	}
+}
+
+// sectionHash 返回内容中Ghost段的摘要，不存在Ghost段时返回空字符串
+func sectionHash(content string) string {
+	section, ok := system.ExtractGhostSection(content)
+	if !ok {
+		return ""
+	}
+	return hashContent(section)
+}
@@ -0,0 +1,109 @@
+package application
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"ghost/models"
+	"ghost/system"
+)
+
+// editSection 在Ghost段结束标记之前插入行，并删除指定的行，模拟手动修改
+func editSection(content string, add []string, remove string) string {
+	if remove != "" {
+		content = strings.Replace(content, remove+"\n", "", 1)
+	}
+	if len(add) > 0 {
+		content = strings.Replace(content, system.GhostSectionEnd, strings.Join(add, "\n")+"\n"+system.GhostSectionEnd, 1)
+	}
+	return content
+}
+
+// TestDetectDrift 测试手动修改的识别，包括只删除行和手动重新启用已禁用分组的条目
+func TestDetectDrift(t *testing.T) {
+	groups := []models.HostGroup{
+		{ID: "1", Name: "Dev", Enabled: true, Content: "10.0.0.1 api.local\n10.0.0.2 web.local"},
+		{ID: "2", Name: "Staging", Enabled: false, Content: "10.0.1.1 api.staging"},
+	}
+	written, err := (&system.HostManager{}).RenderHostGroups("127.0.0.1 localhost\n", buildHostGroups(groups), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		hash    string
+		add     []string
+		remove  string
+		drifted bool
+		edited  []string
+	}{
+		{name: "never written", add: []string{"1.2.3.4 manual.local"}},
+		{name: "unchanged", hash: sectionHash(written)},
+		{name: "added line", hash: sectionHash(written), add: []string{"1.2.3.4 manual.local"}, drifted: true, edited: []string{"1.2.3.4 manual.local"}},
+		{name: "deleted line only", hash: sectionHash(written), remove: "10.0.0.2 web.local", drifted: true},
+		{name: "line of an enabled group", hash: sectionHash(written), add: []string{"10.0.0.1 api.local"}, drifted: true},
+		{
+			name:    "entry of a disabled group",
+			hash:    sectionHash(written),
+			add:     []string{"10.0.1.1 api.staging"},
+			drifted: true,
+			edited:  []string{"10.0.1.1 api.staging"},
+		},
+		{name: "user comment", hash: sectionHash(written), add: []string{"#   api.local -> 10.0.0.9"}, drifted: true, edited: []string{"#   api.local -> 10.0.0.9"}},
+	}
+
+	for _, tt := range tests {
+		manager := &models.HostManager{Groups: groups, LastSectionHash: tt.hash}
+		current := editSection(written, tt.add, tt.remove)
+
+		report := detectDrift(manager, current, written)
+		if report.Drifted != tt.drifted {
+			t.Errorf("%s: expected drifted=%t, got %t", tt.name, tt.drifted, report.Drifted)
+		}
+		if strings.Join(report.EditedLines, "|") != strings.Join(tt.edited, "|") {
+			t.Errorf("%s: expected edited lines %q, got %q", tt.name, tt.edited, report.EditedLines)
+		}
+	}
+}
+
+// TestApplyDriftImportWithoutEdits 测试没有可导入的行时按覆盖处理，不会保存空分组
+func TestApplyDriftImportWithoutEdits(t *testing.T) {
+	app, _ := newTestApp(t)
+	if err := app.AddHostGroup(models.HostGroup{Name: "Dev", Enabled: true, Content: "10.0.0.1 api.local\n10.0.0.2 web.local"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.applyStored(""); err != nil {
+		t.Fatal(err)
+	}
+
+	// 手动删除一行
+	path := app.hostManager.HostsPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(editSection(string(data), nil, "10.0.0.2 web.local")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.applyStored(models.DriftImport); err != nil {
+		t.Fatalf("apply with import failed: %v", err)
+	}
+
+	groups, err := app.GetHostGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 {
+		t.Errorf("expected no imported group, got %d groups", len(groups))
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "10.0.0.2 web.local") {
+		t.Errorf("expected deleted line to be restored by overwrite:\n%s", data)
+	}
+}
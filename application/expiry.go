@@ -0,0 +1,94 @@
+package application
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"ghost/models"
+)
+
+// expiryItemFor 为临时启用的分组生成到期任务
+// 应用关闭期间已经到期的分组在调度器启动后立即处理
+func expiryItemFor(group *models.HostGroup) (*scheduleItem, bool) {
+	if !group.Enabled || group.ExpiresAt == "" {
+		return nil, false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, group.ExpiresAt)
+	if err != nil {
+		log.Printf("Warning: invalid expiry %q for group %s: %v", group.ExpiresAt, group.ID, err)
+		return nil, false
+	}
+	return &scheduleItem{
+		groupID: group.ID,
+		name:    group.Name,
+		kind:    scheduleKindExpiry,
+		due:     expiresAt,
+	}, true
+}
+
+// runExpiry 禁用已到期的分组，返回下一次需要检查的时间
+func (app *HostApp) runExpiry(id string) time.Time {
+	now := wallClock()
+	next, err := app.expireGroup(id, now)
+	if err != nil {
+		log.Printf("Error expiring group %s: %v", id, err)
+		return now.Add(activationRetryDelay)
+	}
+	return next
+}
+
+// expireGroup 到期时禁用分组并安排重新应用；到期时间被修改或取消时返回新的检查时间
+func (app *HostApp) expireGroup(id string, now time.Time) (time.Time, error) {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	var group *models.HostGroup
+	for i := range manager.Groups {
+		if manager.Groups[i].ID == id {
+			group = &manager.Groups[i]
+			break
+		}
+	}
+	if group == nil {
+		return time.Time{}, fmt.Errorf("host group with ID %s not found", id)
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, group.ExpiresAt)
+	if err != nil || !group.Enabled {
+		// 到期时间已被取消，任务会随分组的调度更新被移除
+		app.rescheduleGroup(id)
+		return now.Add(activationRetryDelay), nil
+	}
+	if expiresAt.After(now) {
+		return expiresAt, nil
+	}
+
+	group.Enabled = false
+	group.ExpiresAt = ""
+	group.UpdatedAt = now.Format(time.RFC3339)
+	manager.UpdatedAt = now.Format(time.RFC3339)
+
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to save host manager: %w", err)
+	}
+
+	reason := fmt.Sprintf("temporary enable expired at %s", expiresAt.Format(time.RFC3339))
+	log.Printf("Group %s disabled: %s", group.Name, reason)
+	app.recordSwitch(group, models.SwitchTriggerExpiry, reason)
+	app.rescheduleGroup(id)
+
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		config = &models.AppConfig{}
+	}
+	app.scheduleAutoApply(id, autoApplyDelay(config))
+
+	return now.Add(activationRetryDelay), nil
+}
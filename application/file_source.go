@@ -0,0 +1,170 @@
+package application
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"ghost/models"
+	"ghost/remote"
+)
+
+// localFilePath 将本地文件来源解析为文件系统路径，支持普通路径和file:// URL
+func localFilePath(source string) (string, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return "", fmt.Errorf("local file path cannot be empty")
+	}
+
+	if !strings.HasPrefix(strings.ToLower(source), "file://") {
+		return filepath.Clean(source), nil
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid file URL %q: %w", source, err)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("file URL %q refers to a remote host", source)
+	}
+
+	path := u.Path
+	// file:///C:/hosts 在Windows上对应C:/hosts
+	if runtime.GOOS == "windows" && len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// fileState 文件的修改时间和大小，用于判断文件是否变化
+// 文件无法访问时记录错误信息，错误不变时不必重复处理
+type fileState struct {
+	modTime string
+	size    int64
+	err     string
+}
+
+// statFile 获取文件当前的状态，出错时返回的状态包含错误信息
+func statFile(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{err: err.Error()}, err
+	}
+	if info.IsDir() {
+		err = fmt.Errorf("%s is a directory", path)
+		return fileState{err: err.Error()}, err
+	}
+	return fileState{modTime: info.ModTime().UTC().Format(time.RFC3339Nano), size: info.Size()}, nil
+}
+
+// prepareFileGroup 校验本地文件组的设置并从文件读取内容
+func (app *HostApp) prepareFileGroup(group *models.HostGroup) error {
+	if group.IsRemote {
+		return fmt.Errorf("a group cannot be both remote and a local file")
+	}
+
+	_, err := app.loadFileGroup(group, true)
+	group.RecordRefresh(err, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to load local file group %s: %w", group.Name, err)
+	}
+
+	return nil
+}
+
+// loadFileGroup 读取本地文件来源的内容，force为false时文件未变化则不读取
+// 返回内容是否被重新读取
+func (app *HostApp) loadFileGroup(group *models.HostGroup, force bool) (bool, error) {
+	path, err := localFilePath(group.FilePath)
+	if err != nil {
+		return false, err
+	}
+
+	state, err := statFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read local file: %w", err)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	group.LastChecked = now
+	if !force && state.modTime == group.FileModTime && state.size == group.FileSize {
+		return false, nil
+	}
+
+	config, err := app.configStorage.LoadConfig()
+	if err == nil && config.MaxRemoteSize > 0 && state.size > config.MaxRemoteSize<<20 {
+		return false, &remote.SizeLimitError{URL: group.FilePath, Limit: config.MaxRemoteSize << 20, Size: state.size}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read local file: %w", err)
+	}
+
+	content, issues, err := group.ConvertContent(string(data), group.SourceFormat)
+	if err != nil {
+		return false, fmt.Errorf("invalid content in %s: %w", path, err)
+	}
+
+	group.Content = content
+	group.Issues = issues
+	group.FileModTime = state.modTime
+	group.FileSize = state.size
+	group.LastUpdated = now
+
+	return true, nil
+}
+
+// refreshFileGroup 检查本地文件来源是否变化，变化时重新加载并按设置安排自动应用
+func (app *HostApp) refreshFileGroup(id string, force bool) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	var group *models.HostGroup
+	for i := range manager.Groups {
+		if manager.Groups[i].ID == id {
+			group = &manager.Groups[i]
+			break
+		}
+	}
+	if group == nil {
+		return fmt.Errorf("host group with ID %s not found", id)
+	}
+	if !group.IsFile {
+		return fmt.Errorf("host group is not a local file group")
+	}
+
+	oldContent := group.Content
+	changed, loadErr := app.loadFileGroup(group, force)
+	group.RecordRefresh(loadErr, time.Now())
+	if changed {
+		group.UpdatedAt = time.Now().Format(time.RFC3339)
+	}
+	manager.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return fmt.Errorf("failed to save host manager: %w", err)
+	}
+	if loadErr != nil {
+		return fmt.Errorf("failed to update local file group: %w", loadErr)
+	}
+
+	if group.Content != oldContent {
+		log.Printf("Local file group %s reloaded from %s", group.Name, group.FilePath)
+		app.recordGroupChange(group, oldContent)
+		app.maybeAutoApply(group)
+	}
+
+	return nil
+}
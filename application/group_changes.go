@@ -0,0 +1,88 @@
+package application
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ghost/hosts"
+	"ghost/models"
+)
+
+const (
+	// maxChangeEntries 每条变化记录中每类条目最多保存的数量
+	maxChangeEntries = 100
+	// maxChangeDiffLines 每条变化记录中diff最多保存的行数
+	maxChangeDiffLines = 200
+)
+
+// ListGroupChanges 列出指定分组的内容变化记录（最新的在前）
+func (app *HostApp) ListGroupChanges(id string) ([]models.GroupChange, error) {
+	return app.configStorage.ListGroupChanges(id)
+}
+
+// recordGroupChange 比较刷新前后的内容并保存变化记录，内容未变化时不记录，失败时只记录日志
+func (app *HostApp) recordGroupChange(group *models.HostGroup, oldContent string) {
+	if group.Content == oldContent {
+		return
+	}
+
+	change := newGroupChange(group, oldContent)
+	log.Printf("Group %s changed: %d added, %d removed, %d changed", group.Name, change.Added, change.Removed, change.Changed)
+
+	err := app.configStorage.SaveGroupChange(change)
+	if err != nil {
+		log.Printf("Warning: failed to save change record for group %s: %v", group.Name, err)
+	}
+}
+
+// newGroupChange 生成分组内容的变化记录，条目列表和diff超出上限时截断
+func newGroupChange(group *models.HostGroup, oldContent string) *models.GroupChange {
+	now := time.Now()
+	changes := hosts.DiffEntries(oldContent, group.Content)
+	change := &models.GroupChange{
+		// ID以时间戳开头，保证按字典序排列即为时间顺序
+		ID:        now.Format("20060102_150405.000") + "-" + uuid.New().String()[:8],
+		GroupID:   group.ID,
+		Timestamp: now.Format(time.RFC3339),
+		Source:    changeSource(group),
+		Added:     len(changes.Added),
+		Removed:   len(changes.Removed),
+		Changed:   len(changes.Changed),
+	}
+
+	for _, list := range []*[]hosts.EntryChange{&changes.Added, &changes.Removed, &changes.Changed} {
+		if len(*list) > maxChangeEntries {
+			*list = (*list)[:maxChangeEntries]
+			change.Truncated = true
+		}
+	}
+	change.Changes = changes
+
+	diff := hosts.UnifiedDiff("before", "after", oldContent, group.Content, hosts.DefaultDiffContext)
+	lines := strings.SplitAfter(diff, "\n")
+	if len(lines) > maxChangeDiffLines {
+		diff = strings.Join(lines[:maxChangeDiffLines], "") + "...\n"
+		change.Truncated = true
+	}
+	change.Diff = diff
+
+	return change
+}
+
+// changeSource 返回分组内容的来源描述
+func changeSource(group *models.HostGroup) string {
+	if group.IsFile {
+		return group.FilePath
+	}
+	if group.EffectiveSourceMode() == models.SourceModeMerge && len(group.Sources) > 1 {
+		urls := make([]string, len(group.Sources))
+		for i, source := range group.Sources {
+			urls[i] = source.URL
+		}
+		return strings.Join(urls, ", ")
+	}
+	return group.CurrentSourceURL()
+}
@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
+	"ghost/hosts"
 	"ghost/models"
 	"ghost/remote"
 	"ghost/storage"
@@ -19,13 +21,31 @@ import (
 // HostApp 主应用程序逻辑
 type HostApp struct {
 	configStorage *storage.ConfigStorage
-	hostManager   *system.HostManager
-	mu            sync.RWMutex
-	autoRefresh   bool
-	stopChan      chan struct{}
-	// 用于管理单个远程组的定时刷新
-	remoteRefreshTimers map[string]*time.Ticker
-	remoteRefreshCancel map[string]chan struct{}
+	hostManager   hostsFile
+	// 保护分组数据的读取-修改-保存，避免后台任务和界面操作互相覆盖
+	// 耗时的网络请求不能在持有锁时进行
+	dataMu sync.Mutex
+	// 统一调度远程组刷新和本地文件检查
+	scheduler *scheduler
+	// 自动应用的防抖状态
+	autoApplyMu      sync.Mutex
+	autoApplyTimer   *time.Timer
+	autoApplyPending map[string]bool
+	autoApplyStatus  models.AutoApplyStatus
+}
+
+// hostsFile 系统hosts文件的读写和权限操作，由system.HostManager实现，测试中可以替换
+type hostsFile interface {
+	HostsPath() string
+	ReadSystemHosts() (string, error)
+	ReadUnmanagedHosts() (string, error)
+	WriteSystemHosts(content string) error
+	RenderHostGroups(currentContent string, hostGroups []map[string]interface{}, mergeMode string) (string, error)
+	HasWritePermission() bool
+	RequestElevatedPrivileges() error
+	RequestAdminPrivileges() error
+	GetAppDataDir() (string, error)
+	RestoreRawSystemHosts(backupFilePath string) error
 }
 
 // NewHostApp 创建新的Host应用程序实例
@@ -38,13 +58,11 @@ func NewHostApp() (*HostApp, error) {
 	hostManager := system.NewHostManager()
 
 	app := &HostApp{
-		configStorage:       configStorage,
-		hostManager:         hostManager,
-		autoRefresh:         false,
-		stopChan:            make(chan struct{}),
-		remoteRefreshTimers: make(map[string]*time.Ticker),
-		remoteRefreshCancel: make(map[string]chan struct{}),
+		configStorage:    configStorage,
+		hostManager:      hostManager,
+		autoApplyPending: make(map[string]bool),
 	}
+	app.scheduler = newScheduler(app.runScheduledJob, defaultRefreshWorkers)
 
 	return app, nil
 }
@@ -56,11 +74,20 @@ func (app *HostApp) GetHostGroups() ([]models.HostGroup, error) {
 		return nil, fmt.Errorf("failed to load host manager: %w", err)
 	}
 
+	now := time.Now()
+	for i := range manager.Groups {
+		manager.Groups[i].UpdateRemaining(now)
+		app.fillNextAttempt(&manager.Groups[i])
+	}
+
 	return manager.Groups, nil
 }
 
 // AddHostGroup 添加新的Host分组
 func (app *HostApp) AddHostGroup(group models.HostGroup) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
 	manager, err := app.configStorage.LoadHostManager()
 	if err != nil {
 		return fmt.Errorf("failed to load host manager: %w", err)
@@ -77,13 +104,17 @@ func (app *HostApp) AddHostGroup(group models.HostGroup) error {
 	}
 
 	// 验证必要字段（不再需要验证ID，因为是自动生成的）
-	if group.Name == "" {
-		return fmt.Errorf("group name cannot be empty")
+	if err := validateHostGroup(&group); err != nil {
+		return err
 	}
 
-	// 如果是远程组，验证URL
-	if group.IsRemote && strings.TrimSpace(group.URL) == "" {
-		return fmt.Errorf("remote group URL cannot be empty")
+	// 本地文件组从文件读取内容，其他组按照校验策略检查内容
+	if group.IsFile {
+		if err := app.prepareFileGroup(&group); err != nil {
+			return err
+		}
+	} else if err := app.sanitizeGroupContent(&group); err != nil {
+		return err
 	}
 
 	// 设置默认值
@@ -100,33 +131,43 @@ func (app *HostApp) AddHostGroup(group models.HostGroup) error {
 		return fmt.Errorf("failed to save host manager: %w", err)
 	}
 
+	// 需要定时刷新的分组加入调度
+	app.rescheduleGroup(group.ID)
+
 	return nil
 }
 
 // UpdateHostGroup 更新Host分组
 func (app *HostApp) UpdateHostGroup(group models.HostGroup) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
 	manager, err := app.configStorage.LoadHostManager()
 	if err != nil {
 		return fmt.Errorf("failed to load host manager: %w", err)
 	}
 
-	var oldGroup *models.HostGroup
 	updated := false
 	for i, existingGroup := range manager.Groups {
 		if existingGroup.ID == group.ID {
-			oldGroup = &manager.Groups[i]
 			// 验证必要字段
-			if group.Name == "" {
-				return fmt.Errorf("group name cannot be empty")
+			if err := validateHostGroup(&group); err != nil {
+				return err
 			}
 
-			// 如果是远程组，验证URL
-			if group.IsRemote && strings.TrimSpace(group.URL) == "" {
-				return fmt.Errorf("remote group URL cannot be empty")
+			// 保留创建时间和后端维护的状态
+			group.CreatedAt = existingGroup.CreatedAt
+			group.KeepRuntimeState(&existingGroup)
+
+			// 本地文件组从文件读取内容，其他组按照校验策略检查内容
+			if group.IsFile {
+				if err := app.prepareFileGroup(&group); err != nil {
+					return err
+				}
+			} else if err := app.sanitizeGroupContent(&group); err != nil {
+				return err
 			}
 
-			// 保留创建时间
-			group.CreatedAt = existingGroup.CreatedAt
 			group.UpdatedAt = time.Now().Format(time.RFC3339)
 
 			manager.Groups[i] = group
@@ -145,37 +186,75 @@ func (app *HostApp) UpdateHostGroup(group models.HostGroup) error {
 		return fmt.Errorf("failed to save host manager: %w", err)
 	}
 
-	// 如果是远程组，根据刷新间隔设置启动或停止定时刷新
-	if group.IsRemote {
-		// 如果新的刷新间隔大于0，启动定时刷新
-		if group.RefreshInterval > 0 {
-			err := app.StartRemoteGroupRefreshTimer(group.ID)
-			if err != nil {
-				log.Printf("Error starting refresh timer for group %s: %v", group.ID, err)
-			}
-		} else if oldGroup != nil && oldGroup.RefreshInterval > 0 {
-			// 如果之前有定时刷新，但现在设置为0，则停止定时刷新
-			app.StopRemoteGroupRefreshTimer(group.ID)
+	// 按新的刷新间隔和来源更新调度
+	app.rescheduleGroup(group.ID)
+
+	return nil
+}
+
+// validateHostGroup 校验添加或更新的分组设置，并规范化远程来源
+func validateHostGroup(group *models.HostGroup) error {
+	if group.Name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	// 如果是远程组，验证来源和URL
+	if err := group.NormalizeSources(); err != nil {
+		return err
+	}
+	if group.IsRemote && strings.TrimSpace(group.URL) == "" {
+		return fmt.Errorf("remote group URL cannot be empty")
+	}
+
+	// 验证代理、完整性、来源格式和启用计划设置
+	if err := remote.ValidateProxy(group.Proxy); err != nil {
+		return err
+	}
+	if err := remote.ValidateIntegrity(group); err != nil {
+		return err
+	}
+	if err := hosts.ValidateFormat(group.SourceFormat, group.SinkAddress); err != nil {
+		return err
+	}
+	if group.Activation != nil {
+		if err := group.Activation.Validate(); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// sanitizeGroupContent 按照组的校验策略检查并处理组内容
+func (app *HostApp) sanitizeGroupContent(group *models.HostGroup) error {
+	content, err := group.SanitizeContent(group.Content)
+	if err != nil {
+		return fmt.Errorf("invalid content for group %s: %w", group.Name, err)
+	}
+
+	if len(group.Issues) > 0 {
+		log.Printf("Group %s has %d invalid lines (policy: %s)", group.Name, len(group.Issues), group.EffectiveValidationPolicy())
+	}
+	group.Content = content
+
+	return nil
+}
+
 // DeleteHostGroup 删除Host分组
 func (app *HostApp) DeleteHostGroup(id string) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
 	manager, err := app.configStorage.LoadHostManager()
 	if err != nil {
 		return fmt.Errorf("failed to load host manager: %w", err)
 	}
 
-	var deletedGroup *models.HostGroup
 	updatedGroups := make([]models.HostGroup, 0, len(manager.Groups))
 	found := false
 
 	for _, group := range manager.Groups {
 		if group.ID == id {
-			deletedGroup = &group
 			found = true
 			continue
 		}
@@ -187,6 +266,7 @@ func (app *HostApp) DeleteHostGroup(id string) error {
 	}
 
 	manager.Groups = updatedGroups
+	removeGroupFromProfiles(manager, id)
 	manager.UpdatedAt = time.Now().Format(time.RFC3339)
 
 	err = app.configStorage.SaveHostManager(manager)
@@ -194,28 +274,51 @@ func (app *HostApp) DeleteHostGroup(id string) error {
 		return fmt.Errorf("failed to save host manager: %w", err)
 	}
 
-	// 如果删除的是远程组，停止其定时刷新
-	if deletedGroup != nil && deletedGroup.IsRemote {
-		app.StopRemoteGroupRefreshTimer(id)
+	app.unscheduleGroup(id)
+
+	err = app.configStorage.DeleteGroupChanges(id)
+	if err != nil {
+		log.Printf("Warning: failed to delete change records for group %s: %v", id, err)
+	}
+
+	// 无论分组当前是否为远程组都删除保存的凭据，分组可能曾经是远程组
+	err = app.configStorage.DeleteCredentials(id)
+	if err != nil {
+		log.Printf("Warning: failed to delete credentials for group %s: %v", id, err)
 	}
 
 	return nil
 }
 
-// ToggleHostGroup 启用或禁用Host分组
+// ToggleHostGroup 启用或禁用Host分组，同时取消之前设置的到期时间
 func (app *HostApp) ToggleHostGroup(id string, enabled bool) error {
+	return app.setGroupEnabled(id, enabled, "")
+}
+
+// EnableHostGroupFor 临时启用Host分组，经过指定时长后自动禁用并重新应用
+func (app *HostApp) EnableHostGroupFor(id string, d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("expiry must be positive")
+	}
+	return app.setGroupEnabled(id, true, time.Now().Add(d).Format(time.RFC3339))
+}
+
+// setGroupEnabled 设置分组的启用状态和到期时间，expiresAt为空表示不会自动禁用
+func (app *HostApp) setGroupEnabled(id string, enabled bool, expiresAt string) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
 	manager, err := app.configStorage.LoadHostManager()
 	if err != nil {
 		return fmt.Errorf("failed to load host manager: %w", err)
 	}
 
-	var targetGroup *models.HostGroup
 	updated := false
 	for i, group := range manager.Groups {
 		if group.ID == id {
 			manager.Groups[i].Enabled = enabled
+			manager.Groups[i].ExpiresAt = expiresAt
 			manager.Groups[i].UpdatedAt = time.Now().Format(time.RFC3339)
-			targetGroup = &manager.Groups[i]
 			updated = true
 			break
 		}
@@ -231,44 +334,108 @@ func (app *HostApp) ToggleHostGroup(id string, enabled bool) error {
 		return fmt.Errorf("failed to save host manager: %w", err)
 	}
 
-	// 如果是远程组且设置了刷新间隔，启用时启动定时器，禁用时停止定时器
-	if targetGroup != nil && targetGroup.IsRemote && targetGroup.RefreshInterval > 0 {
-		if enabled {
-			// 启用组时启动定时刷新
-			err := app.StartRemoteGroupRefreshTimer(id)
-			if err != nil {
-				log.Printf("Error starting refresh timer for group %s: %v", id, err)
-			}
-		} else {
-			// 禁用组时停止定时刷新
-			app.StopRemoteGroupRefreshTimer(id)
-		}
-	}
+	// 启用时加入调度，禁用时移出调度，有到期时间时安排到期后禁用
+	app.rescheduleGroup(id)
 
 	return nil
 }
 
-// ApplyHosts 应用所有启用的Host分组到系统
-func (app *HostApp) ApplyHosts() error {
-	// 检查权限
-	if !app.hostManager.HasWritePermission() {
-		// 尝试以管理员权限重新启动（仅在必要时）
-		err := app.requestAdminPrivileges()
-		if err != nil {
-			return err
+// ReorderHostGroups 按照给定的ID顺序重新排列Host分组，排在前面的分组优先级更高
+// 未出现在列表中的分组保持原有相对顺序，排在列表中的分组之后
+func (app *HostApp) ReorderHostGroups(ids []string) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	index := make(map[string]int, len(manager.Groups))
+	for i, group := range manager.Groups {
+		index[group.ID] = i
+	}
+
+	ordered := make([]models.HostGroup, 0, len(manager.Groups))
+	placed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		i, exists := index[id]
+		if !exists {
+			return fmt.Errorf("host group with ID %s not found", id)
+		}
+		if placed[id] {
+			return fmt.Errorf("duplicate host group ID %s in order", id)
+		}
+		placed[id] = true
+		ordered = append(ordered, manager.Groups[i])
+	}
+	for _, group := range manager.Groups {
+		if !placed[group.ID] {
+			ordered = append(ordered, group)
 		}
-		// 如果重新启动成功，这里不会执行到
-		return app.hostManager.RequestAdminPrivileges()
 	}
 
+	// 优先级从高到低依次递减，保证最后一个分组的优先级为1
+	for i := range ordered {
+		ordered[i].Priority = len(ordered) - i
+	}
+
+	manager.Groups = ordered
+	manager.UpdatedAt = time.Now().Format(time.RFC3339)
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return fmt.Errorf("failed to save host manager: %w", err)
+	}
+
+	return nil
+}
+
+// PreviewApply 预览应用所有启用的Host分组后系统hosts文件的变化，不修改任何文件
+func (app *HostApp) PreviewApply() (*models.ApplyPreview, error) {
 	manager, err := app.configStorage.LoadHostManager()
 	if err != nil {
-		return fmt.Errorf("failed to load host manager: %w", err)
+		return nil, fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// 构建hostGroups数据结构用于应用到系统
+	config = manager.EffectiveConfig(config)
+
+	currentContent, err := app.hostManager.ReadSystemHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system hosts file: %w", err)
+	}
+
+	newContent, err := app.hostManager.RenderHostGroups(currentContent, buildHostGroups(manager.Groups), config.MergeMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render host groups: %w", err)
+	}
+
+	conflicts, err := app.findConflicts(manager.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := hosts.DiffEntries(currentContent, newContent)
+	path := app.hostManager.HostsPath()
+	return &models.ApplyPreview{
+		Content:   newContent,
+		Diff:      hosts.UnifiedDiff(path, path+" (preview)", currentContent, newContent, hosts.DefaultDiffContext),
+		Added:     len(changes.Added),
+		Removed:   len(changes.Removed),
+		Changed:   len(changes.Changed),
+		Changes:   changes,
+		Conflicts: conflicts,
+	}, nil
+}
+
+// buildHostGroups 将启用的分组按优先级从高到低转换为HostManager使用的数据结构
+func buildHostGroups(groups []models.HostGroup) []map[string]interface{} {
 	var hostGroups []map[string]interface{}
-	for _, group := range manager.Groups {
+	for _, group := range sortGroupsByPriority(groups) {
 		if group.Enabled {
 			hostGroup := map[string]interface{}{
 				"id":       group.ID,
@@ -278,18 +445,203 @@ func (app *HostApp) ApplyHosts() error {
 				"isRemote": group.IsRemote,
 			}
 			hostGroups = append(hostGroups, hostGroup)
-			log.Printf("Applying group: %s (Remote: %t, Enabled: %t)", group.Name, group.IsRemote, group.Enabled)
 		}
 	}
+	return hostGroups
+}
+
+// sortGroupsByPriority 返回按优先级从高到低排序的分组副本，优先级相同时保持原有顺序
+func sortGroupsByPriority(groups []models.HostGroup) []models.HostGroup {
+	sorted := make([]models.HostGroup, len(groups))
+	copy(sorted, groups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	return sorted
+}
+
+// ApplyHosts 应用所有启用的Host分组到系统
+// 如果Ghost段在上次写入后被手动修改，返回DriftError，由调用者选择处理方式后调用ApplyHostsWithResolution
+func (app *HostApp) ApplyHosts() error {
+	return app.ApplyHostsWithResolution("")
+}
+
+// ApplyHostsWithResolution 应用所有启用的Host分组到系统，resolution指定检测到手动修改时的处理方式
+// resolution可以为import、overwrite、abort，为空时检测到手动修改返回DriftError
+func (app *HostApp) ApplyHostsWithResolution(resolution string) error {
+	// 检查权限
+	err := app.ensureWritePermission()
+	if err != nil {
+		return err
+	}
+
+	return app.applyStored(resolution)
+}
+
+// applyStored 读取保存的分组和配置并写入系统hosts文件，不检查权限
+func (app *HostApp) applyStored(resolution string) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	_, err = app.applyManager(manager, config, resolution)
+	return err
+}
+
+// applyManager 将给定的分组和配置写入系统hosts文件，成功后保存分组数据，返回是否已写入
+// 写入前的任何失败或放弃都不会修改系统hosts文件和保存的数据，保存失败时恢复写入前的系统hosts文件
+func (app *HostApp) applyManager(manager *models.HostManager, config *models.AppConfig, resolution string) (bool, error) {
+	// 叠加当前配置方案的覆盖设置
+	config = manager.EffectiveConfig(config)
 
-	// 使用HostManager的ApplyHostGroups方法，该方法会保留系统原有内容
-	err = app.hostManager.ApplyHostGroups(hostGroups)
+	// 检查启用的分组之间以及与系统原有条目之间的冲突
+	err := app.checkConflicts(config, manager.Groups)
 	if err != nil {
-		return fmt.Errorf("failed to apply host groups to system: %w", err)
+		return false, err
+	}
+
+	currentContent, err := app.hostManager.ReadSystemHosts()
+	if err != nil {
+		return false, fmt.Errorf("failed to read current system hosts: %w", err)
+	}
+
+	// 使用HostManager生成新内容，该方法会保留系统原有内容
+	newContent, err := app.hostManager.RenderHostGroups(currentContent, buildHostGroups(manager.Groups), config.MergeMode)
+	if err != nil {
+		return false, fmt.Errorf("failed to apply host groups to system: %w", err)
+	}
+
+	// 检查Ghost段是否在上次写入后被手动修改
+	report := detectDrift(manager, currentContent, newContent)
+	if report.Drifted {
+		switch resolution {
+		case "":
+			return false, &DriftError{Report: report}
+		case models.DriftAbort:
+			log.Println("Apply aborted: ghost section was modified outside of Ghost")
+			return false, nil
+		case models.DriftOverwrite:
+			log.Printf("Overwriting %d manually edited lines in ghost section", len(report.EditedLines))
+		case models.DriftImport:
+			// 只删除了行或修改的行都已属于启用的分组时没有可导入的内容，按覆盖处理，避免保存空分组
+			if len(report.EditedLines) == 0 {
+				log.Println("No manually edited lines to import, overwriting ghost section")
+				break
+			}
+			group := app.newImportedGroup(report.EditedLines)
+			manager.Groups = append(manager.Groups, group)
+			log.Printf("Imported %d manually edited lines into new group %s", len(report.EditedLines), group.Name)
+
+			newContent, err = app.hostManager.RenderHostGroups(currentContent, buildHostGroups(manager.Groups), config.MergeMode)
+			if err != nil {
+				return false, fmt.Errorf("failed to apply host groups to system: %w", err)
+			}
+		default:
+			return false, fmt.Errorf("unknown drift resolution: %s", resolution)
+		}
+	}
+
+	hostGroups := buildHostGroups(manager.Groups)
+	for _, group := range hostGroups {
+		log.Printf("Applying group: %s (Remote: %t, Enabled: %t)", group["name"], group["isRemote"], group["enabled"])
+	}
+
+	err = app.hostManager.WriteSystemHosts(newContent)
+	if err != nil {
+		return false, fmt.Errorf("failed to apply host groups to system: %w", err)
 	}
 
 	log.Printf("Applied %d enabled host groups to system hosts file", len(hostGroups))
 
+	// 记录本次写入的Ghost段摘要，用于下次检测手动修改
+	manager.LastSectionHash = sectionHash(newContent)
+	manager.UpdatedAt = time.Now().Format(time.RFC3339)
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		if restoreErr := app.hostManager.WriteSystemHosts(currentContent); restoreErr != nil {
+			return true, fmt.Errorf("failed to save host manager: %w (restoring the system hosts file also failed: %v)", err, restoreErr)
+		}
+		return false, fmt.Errorf("failed to save host manager: %w", err)
+	}
+
+	// 记录应用历史，失败不影响本次应用
+	app.recordApply(models.ApplyKindApply, currentContent, newContent, enabledGroupIDs(manager.Groups), "")
+
+	return true, nil
+}
+
+// ensureWritePermission 检查是否有写入系统hosts文件的权限，没有时尝试提升权限
+func (app *HostApp) ensureWritePermission() error {
+	if app.hostManager.HasWritePermission() {
+		return nil
+	}
+
+	// 尝试以管理员权限重新启动（仅在必要时）
+	err := app.requestAdminPrivileges()
+	if err != nil {
+		return err
+	}
+	// 如果重新启动成功，这里不会执行到
+	return app.hostManager.RequestAdminPrivileges()
+}
+
+// DetectConflicts 分析所有启用的分组和系统hosts文件中不由Ghost管理的部分，返回主机名冲突
+func (app *HostApp) DetectConflicts() ([]hosts.Conflict, error) {
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	return app.findConflicts(manager.Groups)
+}
+
+// findConflicts 对指定分组中启用的部分和系统原有条目进行冲突分析
+func (app *HostApp) findConflicts(groups []models.HostGroup) ([]hosts.Conflict, error) {
+	unmanaged, err := app.hostManager.ReadUnmanagedHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system hosts file: %w", err)
+	}
+
+	sources := []hosts.Source{{ID: "system", Name: "system hosts", Content: unmanaged}}
+	for _, group := range groups {
+		if group.Enabled {
+			sources = append(sources, hosts.Source{ID: group.ID, Name: group.Name, Content: group.Content})
+		}
+	}
+
+	return hosts.FindConflicts(sources), nil
+}
+
+// checkConflicts 按照配置的冲突策略检查冲突，策略为refuse且存在冲突时返回ConflictError
+func (app *HostApp) checkConflicts(config *models.AppConfig, groups []models.HostGroup) error {
+	if config.ConflictPolicy == models.ConflictPolicyIgnore {
+		return nil
+	}
+
+	conflicts, err := app.findConflicts(groups)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	if config.ConflictPolicy == models.ConflictPolicyRefuse {
+		return &hosts.ConflictError{Conflicts: conflicts}
+	}
+
+	for _, conflict := range conflicts {
+		log.Printf("Warning: conflicting hostname %s", conflict)
+	}
 	return nil
 }
 
@@ -304,109 +656,98 @@ func (app *HostApp) GetSystemHostsContent() (string, error) {
 
 // GetSystemHostPath 获取系统hosts文件路径
 func (app *HostApp) GetSystemHostPath() string {
-	return app.hostManager.SystemHostPath
+	return app.hostManager.HostsPath()
 }
 
 // RefreshRemoteGroups 刷新所有远程Host组
+// 网络请求在锁外基于分组的副本进行，完成后逐个合并到最新保存的数据中
 func (app *HostApp) RefreshRemoteGroups() error {
-	manager, err := app.configStorage.LoadHostManager()
+	groups, err := app.GetHostGroups()
 	if err != nil {
-		return fmt.Errorf("failed to load host manager: %w", err)
+		return err
 	}
 
-	remoteFetcher := remote.NewRemoteFetcher()
-	updated := false
-
-	for i := range manager.Groups {
-		group := &manager.Groups[i]
-		if group.IsRemote && group.URL != "" {
-			log.Printf("Fetching remote content from URL: %s for group: %s", group.URL, group.Name)
-			oldContent := group.Content
-			err := remoteFetcher.UpdateRemoteHostGroup(group)
-			if err != nil {
-				log.Printf("Error updating remote group %s from URL %s: %v", group.Name, group.URL, err)
-				continue
-			}
+	remoteFetcher := app.newRemoteFetcher()
+	var changed []*models.HostGroup
 
-			// 检查内容是否有变化
-			if oldContent != group.Content {
-				log.Printf("Remote group %s updated with new content", group.Name)
-				updated = true
-			} else {
-				log.Printf("Remote group %s content unchanged", group.Name)
-			}
+	for i := range groups {
+		group := &groups[i]
+		if !group.IsRemote || group.URL == "" {
+			continue
 		}
-	}
 
-	if updated {
-		manager.UpdatedAt = time.Now().Format(time.RFC3339)
-		err = app.configStorage.SaveHostManager(manager)
+		log.Printf("Fetching remote content from URL: %s for group: %s", group.URL, group.Name)
+		oldContent := group.Content
+		fetchErr := remoteFetcher.UpdateRemoteHostGroup(group)
+		// 无论成功与否，健康状态和最后检查时间都需要保存
+		group.RecordRefresh(fetchErr, time.Now())
+
+		merged, err := app.saveRefreshedGroup(group, false)
 		if err != nil {
-			return fmt.Errorf("failed to save updated host manager: %w", err)
+			log.Printf("Error saving remote group %s: %v", group.Name, err)
+			continue
+		}
+		if fetchErr != nil {
+			log.Printf("Error updating remote group %s from URL %s: %v", group.Name, group.URL, fetchErr)
+			continue
+		}
+
+		// 检查内容是否有变化
+		if oldContent != merged.Content {
+			log.Printf("Remote group %s updated with new content", group.Name)
+			app.recordGroupChange(merged, oldContent)
+			changed = append(changed, merged)
+		} else {
+			log.Printf("Remote group %s content unchanged", group.Name)
 		}
-		log.Println("Successfully saved updated host manager with new remote content")
+	}
+
+	// 内容变化的分组按照设置安排自动应用，多个分组会合并为一次写入
+	for _, group := range changed {
+		app.maybeAutoApply(group)
 	}
 
 	return nil
 }
 
-// StartAutoRefresh 启动自动刷新功能
-func (app *HostApp) StartAutoRefresh() error {
-	app.mu.Lock()
-	defer app.mu.Unlock()
+// saveRefreshedGroup 在锁内重新读取数据，将刷新结果合并到最新的分组后保存，返回合并后的分组副本
+// touch为true时同时更新分组的修改时间
+func (app *HostApp) saveRefreshedGroup(refreshed *models.HostGroup, touch bool) (*models.HostGroup, error) {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
 
-	if app.autoRefresh {
-		return fmt.Errorf("auto refresh is already running")
-	}
-
-	config, err := app.configStorage.LoadConfig()
+	manager, err := app.configStorage.LoadHostManager()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load host manager: %w", err)
 	}
 
-	if !config.AutoRefresh {
-		return fmt.Errorf("auto refresh is disabled in config")
+	var target *models.HostGroup
+	for i := range manager.Groups {
+		if manager.Groups[i].ID == refreshed.ID {
+			target = &manager.Groups[i]
+			break
+		}
 	}
-
-	app.autoRefresh = true
-	interval := config.RefreshInterval
-	if interval == 0 {
-		interval = 3600 // 默认1小时（秒）
+	if target == nil {
+		return nil, fmt.Errorf("host group with ID %s not found", refreshed.ID)
+	}
+	if !target.MergeRefresh(refreshed) {
+		return nil, fmt.Errorf("host group %s was modified during refresh, result discarded", target.Name)
 	}
 
-	go func() {
-		// 将秒数转换为Duration
-		ticker := time.NewTicker(time.Duration(interval) * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				log.Println("Refreshing remote host groups...")
-				err := app.RefreshRemoteGroups()
-				if err != nil {
-					log.Printf("Error refreshing remote groups: %v", err)
-				}
-			case <-app.stopChan:
-				log.Println("Stopping auto refresh...")
-				return
-			}
-		}
-	}()
-
-	return nil
-}
-
-// StopAutoRefresh 停止自动刷新功能
-func (app *HostApp) StopAutoRefresh() {
-	app.mu.Lock()
-	defer app.mu.Unlock()
+	now := time.Now().Format(time.RFC3339)
+	if touch {
+		target.UpdatedAt = now
+	}
+	manager.UpdatedAt = now
 
-	if app.autoRefresh {
-		close(app.stopChan)
-		app.autoRefresh = false
-		app.stopChan = make(chan struct{})
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save host manager: %w", err)
 	}
+
+	merged := *target
+	return &merged, nil
 }
 
 // GetConfig 获取应用程序配置
@@ -416,8 +757,27 @@ func (app *HostApp) GetConfig() (*models.AppConfig, error) {
 
 // UpdateConfig 更新应用程序配置
 func (app *HostApp) UpdateConfig(config *models.AppConfig) error {
+	if err := remote.ValidateProxy(config.Proxy); err != nil {
+		return err
+	}
+
+	app.dataMu.Lock()
 	config.UpdatedAt = time.Now().Format(time.RFC3339)
-	return app.configStorage.SaveConfig(config)
+	err := app.configStorage.SaveConfig(config)
+	app.dataMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// 全局刷新设置变化后重新计算调度计划
+	if app.scheduler.isRunning() {
+		err = app.syncSchedule()
+		if err != nil {
+			log.Printf("Error updating schedule: %v", err)
+		}
+	}
+
+	return nil
 }
 
 // BackupConfig 创建配置备份
@@ -439,6 +799,8 @@ func (app *HostApp) GetHostGroup(id string) (*models.HostGroup, error) {
 
 	for _, group := range manager.Groups {
 		if group.ID == id {
+			group.UpdateRemaining(time.Now())
+			app.fillNextAttempt(&group)
 			return &group, nil
 		}
 	}
@@ -446,55 +808,85 @@ func (app *HostApp) GetHostGroup(id string) (*models.HostGroup, error) {
 	return nil, fmt.Errorf("host group with ID %s not found", id)
 }
 
-// RefreshRemoteGroup 刷新指定的远程Host分组
-func (app *HostApp) RefreshRemoteGroup(id string) error {
-	manager, err := app.configStorage.LoadHostManager()
+// GetHostGroupEntries 获取指定Host分组解析后的条目
+func (app *HostApp) GetHostGroupEntries(id string) ([]hosts.Entry, error) {
+	group, err := app.GetHostGroup(id)
 	if err != nil {
-		return fmt.Errorf("failed to load host manager: %w", err)
+		return nil, err
 	}
 
-	var targetGroup *models.HostGroup
-	for i := range manager.Groups {
-		if manager.Groups[i].ID == id {
-			targetGroup = &manager.Groups[i]
-			break
-		}
+	return group.Entries(), nil
+}
+
+// RefreshRemoteGroup 刷新指定的远程Host分组
+func (app *HostApp) RefreshRemoteGroup(id string) error {
+	targetGroup, err := app.GetHostGroup(id)
+	if err != nil {
+		return err
 	}
 
-	if targetGroup == nil {
-		return fmt.Errorf("host group with ID %s not found", id)
+	// 本地文件来源强制重新读取
+	if targetGroup.IsFile {
+		return app.refreshFileGroup(id, true)
 	}
 
 	if !targetGroup.IsRemote {
 		return fmt.Errorf("host group is not a remote group")
 	}
 
-	remoteFetcher := remote.NewRemoteFetcher()
-	err = remoteFetcher.UpdateRemoteHostGroup(targetGroup)
+	// 网络请求在锁外基于分组的副本进行，可能持续较长时间
+	remoteFetcher := app.newRemoteFetcher()
+	oldContent := targetGroup.Content
+	fetchErr := remoteFetcher.UpdateRemoteHostGroup(targetGroup)
+
+	// 失败时也保存健康状态，以便界面提示失效的远程源
+	targetGroup.RecordRefresh(fetchErr, time.Now())
+	merged, err := app.saveRefreshedGroup(targetGroup, fetchErr == nil)
 	if err != nil {
-		return fmt.Errorf("failed to update remote group: %w", err)
+		return err
 	}
 
-	// 更新组的更新时间
-	targetGroup.UpdatedAt = time.Now().Format(time.RFC3339)
-	manager.UpdatedAt = time.Now().Format(time.RFC3339)
+	if fetchErr != nil {
+		return fmt.Errorf("failed to update remote group: %w", fetchErr)
+	}
 
-	err = app.configStorage.SaveHostManager(manager)
-	if err != nil {
-		return fmt.Errorf("failed to save host manager: %w", err)
+	app.recordGroupChange(merged, oldContent)
+	if merged.Content != oldContent {
+		app.maybeAutoApply(merged)
 	}
 
 	return nil
 }
 
+// FetchRemoteContent 按照当前的代理、证书和超时配置获取指定URL的内容，用于添加远程分组前的预览
+func (app *HostApp) FetchRemoteContent(url string) (string, error) {
+	return app.newRemoteFetcher().FetchRemoteHosts(url)
+}
+
+// newRemoteFetcher 根据当前配置创建远程获取器，配置加载失败时使用默认设置
+func (app *HostApp) newRemoteFetcher() *remote.RemoteFetcher {
+	var fetcher *remote.RemoteFetcher
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		log.Printf("Warning: failed to load config for remote fetcher, using defaults: %v", err)
+		fetcher = remote.NewRemoteFetcher()
+	} else {
+		fetcher = remote.NewRemoteFetcherWithConfig(config)
+	}
+	fetcher.SetCredentialsProvider(app.configStorage.LoadCredentials)
+	fetcher.SetContext(app.scheduler.context())
+	return fetcher
+}
+
 // requestAdminPrivileges 尝试以管理员权限重新启动应用
 func (app *HostApp) requestAdminPrivileges() error {
 	return app.hostManager.RequestElevatedPrivileges()
 }
 
-// HostManager 返回HostManager实例
+// HostManager 返回HostManager实例，使用其他hosts文件实现时返回nil
 func (app *HostApp) HostManager() *system.HostManager {
-	return app.hostManager
+	manager, _ := app.hostManager.(*system.HostManager)
+	return manager
 }
 
 // BackupAppAndSystemHosts 同时备份应用数据文件和系统hosts文件
@@ -516,9 +908,17 @@ func (app *HostApp) ListDataBackups() ([]string, error) {
 
 // RestoreData 从备份文件恢复数据
 func (app *HostApp) RestoreData(backupFileName string) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
 	return app.configStorage.RestoreData(backupFileName)
 }
 
+// GetStorageRecoveries 获取本次运行期间数据文件的损坏恢复记录
+func (app *HostApp) GetStorageRecoveries() []models.RecoveryEvent {
+	return app.configStorage.Recoveries()
+}
+
 // HasRawHostsBackup 检查是否存在原始hosts备份文件
 func (app *HostApp) HasRawHostsBackup() (bool, error) {
 	return app.configStorage.HasRawHostsBackup()
@@ -531,7 +931,7 @@ func (app *HostApp) IsBackupDirEmpty() (bool, error) {
 
 // BackupRawSystemHosts 备份当前系统hosts文件
 func (app *HostApp) BackupRawSystemHosts() error {
-	return app.configStorage.BackupRawSystemHosts(app.hostManager.SystemHostPath)
+	return app.configStorage.BackupRawSystemHosts(app.hostManager.HostsPath())
 }
 
 // RestoreRawSystemHosts 从备份恢复系统hosts文件
@@ -545,103 +945,233 @@ func (app *HostApp) RestoreRawSystemHosts(backupFileName string) error {
 	return app.hostManager.RestoreRawSystemHosts(backupFilePath)
 }
 
-// StartRemoteGroupRefreshTimer 启动指定远程组的定时刷新
-func (app *HostApp) StartRemoteGroupRefreshTimer(id string) error {
-	app.mu.Lock()
-	defer app.mu.Unlock()
+// StartScheduler 按当前的分组和配置同步调度计划并启动调度器
+// 上次检查距今已超过刷新间隔的分组会在启动后随机错开地立即刷新
+func (app *HostApp) StartScheduler() error {
+	err := app.syncSchedule()
+	if err != nil {
+		return err
+	}
 
-	// 先停止可能存在的定时器
-	app.stopRemoteGroupRefreshTimer(id)
+	app.scheduler.start()
+	return nil
+}
 
-	// 获取组信息
-	group, err := app.GetHostGroup(id)
-	if err != nil {
-		return fmt.Errorf("failed to get host group: %w", err)
+// StopScheduler 停止调度器并等待正在执行的刷新完成，然后取消等待中的自动应用
+func (app *HostApp) StopScheduler() {
+	if !app.scheduler.shutdown(schedulerShutdownTimeout) {
+		log.Printf("Warning: scheduler did not stop within %s", schedulerShutdownTimeout)
 	}
+	app.stopAutoApply()
+}
 
-	// 检查是否为远程组且设置了刷新间隔
-	if !group.IsRemote || group.RefreshInterval <= 0 {
-		return fmt.Errorf("group is not a remote group or refresh interval is not set")
+// fillNextAttempt 用调度器中实际的下一次执行时间填充分组的健康状态
+func (app *HostApp) fillNextAttempt(group *models.HostGroup) {
+	group.Health.NextAttempt = ""
+	if !group.IsRemote && !group.IsFile {
+		return
 	}
+	if due, ok := app.scheduler.nextRun(scheduleKey(scheduleKindRemote, group.ID)); ok {
+		group.Health.NextAttempt = due.Format(time.RFC3339)
+	}
+}
 
-	// 创建取消通道
-	cancelChan := make(chan struct{})
-	app.remoteRefreshCancel[id] = cancelChan
+// GetSchedule 获取当前的刷新计划
+func (app *HostApp) GetSchedule() []models.ScheduleEntry {
+	return app.scheduler.snapshot()
+}
 
-	// 创建并启动定时器
-	ticker := time.NewTicker(time.Duration(group.RefreshInterval) * time.Second)
-	app.remoteRefreshTimers[id] = ticker
+// syncSchedule 将所有需要定时刷新的分组加入调度，移除不再需要的分组
+func (app *HostApp) syncSchedule() error {
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return fmt.Errorf("failed to load host manager: %w", err)
+	}
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
 
-	log.Printf("Starting refresh timer for remote group %s with interval %ds", id, group.RefreshInterval)
+	scheduled := make(map[string]bool)
+	for i := range manager.Groups {
+		group := &manager.Groups[i]
+		if item, ok := scheduleItemFor(group, config); ok {
+			app.scheduler.upsert(item)
+			scheduled[item.key] = true
+		}
+		if item, ok := activationItemFor(group); ok {
+			app.scheduler.upsert(item)
+			scheduled[item.key] = true
+		}
+		if item, ok := expiryItemFor(group); ok {
+			app.scheduler.upsert(item)
+			scheduled[item.key] = true
+		}
+	}
 
-	// 启动goroutine处理定时刷新
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				log.Printf("Refreshing remote group %s due to timer", id)
-				err := app.RefreshRemoteGroup(id)
-				if err != nil {
-					log.Printf("Error refreshing remote group %s: %v", id, err)
-				}
-			case <-cancelChan:
-				log.Printf("Stopping refresh timer for remote group %s", id)
-				return
-			}
+	for _, key := range app.scheduler.keys(scheduleKindRemote, scheduleKindFile, scheduleKindActivation, scheduleKindExpiry) {
+		if !scheduled[key] {
+			app.scheduler.remove(key)
 		}
-	}()
+	}
 
 	return nil
 }
 
-// StopRemoteGroupRefreshTimer 停止指定远程组的定时刷新
-func (app *HostApp) StopRemoteGroupRefreshTimer(id string) {
-	app.mu.Lock()
-	defer app.mu.Unlock()
+// rescheduleGroup 分组设置变化后更新其调度
+func (app *HostApp) rescheduleGroup(id string) {
+	group, err := app.GetHostGroup(id)
+	if err != nil {
+		app.unscheduleGroup(id)
+		return
+	}
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		log.Printf("Error loading config while scheduling group %s: %v", id, err)
+		return
+	}
 
-	app.stopRemoteGroupRefreshTimer(id)
+	if item, ok := scheduleItemFor(group, config); ok {
+		app.scheduler.upsert(item)
+	} else {
+		app.scheduler.remove(scheduleKey(scheduleKindRemote, id))
+	}
+	if item, ok := activationItemFor(group); ok {
+		app.scheduler.upsert(item)
+	} else {
+		app.scheduler.remove(scheduleKey(scheduleKindActivation, id))
+	}
+	if item, ok := expiryItemFor(group); ok {
+		app.scheduler.upsert(item)
+	} else {
+		app.scheduler.remove(scheduleKey(scheduleKindExpiry, id))
+	}
 }
 
-// stopRemoteGroupRefreshTimer 内部方法，停止指定远程组的定时刷新
-func (app *HostApp) stopRemoteGroupRefreshTimer(id string) {
-	// 停止ticker
-	if ticker, exists := app.remoteRefreshTimers[id]; exists {
-		ticker.Stop()
-		delete(app.remoteRefreshTimers, id)
+// unscheduleGroup 移除分组的所有调度任务
+func (app *HostApp) unscheduleGroup(id string) {
+	app.scheduler.remove(scheduleKey(scheduleKindRemote, id))
+	app.scheduler.remove(scheduleKey(scheduleKindActivation, id))
+	app.scheduler.remove(scheduleKey(scheduleKindExpiry, id))
+}
+
+// scheduleItemFor 根据分组和配置生成调度项，不需要定时刷新的分组返回false
+// 远程分组未设置刷新间隔时，在全局自动刷新开启后使用全局刷新间隔；本地文件分组始终需要监视
+func scheduleItemFor(group *models.HostGroup, config *models.AppConfig) (*scheduleItem, bool) {
+	if !group.Enabled {
+		return nil, false
 	}
 
-	// 关闭取消通道
-	if cancelChan, exists := app.remoteRefreshCancel[id]; exists {
-		close(cancelChan)
-		delete(app.remoteRefreshCancel, id)
+	current := wallClock()
+	switch {
+	case group.IsFile:
+		interval := group.RefreshInterval
+		if interval <= 0 {
+			interval = models.DefaultFileWatchInterval
+		}
+		return &scheduleItem{
+			groupID:   group.ID,
+			name:      group.Name,
+			kind:      scheduleKindFile,
+			interval:  time.Duration(interval) * time.Second,
+			due:       current.Add(stagger()),
+			filePath:  group.FilePath,
+			fileState: fileState{modTime: group.FileModTime, size: group.FileSize},
+		}, true
+
+	case group.IsRemote && group.URL != "":
+		interval := group.RefreshInterval
+		if interval <= 0 && config.AutoRefresh {
+			interval = config.RefreshInterval
+			if interval <= 0 {
+				interval = 3600 // 默认1小时（秒）
+			}
+		}
+		if interval <= 0 {
+			return nil, false
+		}
+
+		item := &scheduleItem{
+			groupID:  group.ID,
+			name:     group.Name,
+			kind:     scheduleKindRemote,
+			interval: time.Duration(interval) * time.Second,
+		}
+		// 从上一次检查的时间开始计算，已经过期的分组错开后立即刷新
+		item.due = current.Add(stagger())
+		if lastChecked, err := time.Parse(time.RFC3339, group.LastChecked); err == nil {
+			if due := lastChecked.Add(item.interval); due.After(item.due) {
+				item.due = due
+			}
+		}
+		return item, true
 	}
+
+	return nil, false
 }
 
-// StartAllRemoteGroupRefreshTimers 启动所有配置了定时刷新的远程组的定时器
-func (app *HostApp) StartAllRemoteGroupRefreshTimers() error {
-	groups, err := app.GetHostGroups()
+// runScheduledJob 在调度器的工作goroutine中执行任务
+func (app *HostApp) runScheduledJob(job scheduleJob) scheduleResult {
+	switch job.kind {
+	case scheduleKindActivation:
+		return scheduleResult{next: app.runActivation(job.groupID)}
+	case scheduleKindExpiry:
+		return scheduleResult{next: app.runExpiry(job.groupID)}
+	case scheduleKindRemote:
+		log.Printf("Refreshing remote group %s on schedule", job.groupID)
+		err := refreshRecovered(func() error { return app.RefreshRemoteGroup(job.groupID) })
+		if err != nil {
+			log.Printf("Error refreshing remote group %s: %v", job.groupID, err)
+		}
+		return scheduleResult{}
+	}
+
+	// 本地文件分组只有修改时间或大小变化时才重新加载
+	// 文件无法访问时交给refreshFileGroup记录状态，之后错误不变时不再重复加载和保存
+	path, err := localFilePath(job.filePath)
 	if err != nil {
-		return fmt.Errorf("failed to get host groups: %w", err)
+		return scheduleResult{fileState: job.fileState}
+	}
+	state, _ := statFile(path)
+	if state == job.fileState {
+		return scheduleResult{fileState: state}
 	}
 
-	for _, group := range groups {
-		if group.IsRemote && group.RefreshInterval > 0 {
-			err := app.StartRemoteGroupRefreshTimer(group.ID)
-			if err != nil {
-				log.Printf("Error starting refresh timer for group %s: %v", group.ID, err)
-			}
-		}
+	err = refreshRecovered(func() error { return app.refreshFileGroup(job.groupID, false) })
+	if err != nil {
+		log.Printf("Error refreshing local file group %s: %v", job.groupID, err)
+	}
+	return scheduleResult{fileState: state}
+}
+
+// StartRemoteGroupRefreshTimer 将指定的远程组或本地文件组加入调度
+func (app *HostApp) StartRemoteGroupRefreshTimer(id string) error {
+	group, err := app.GetHostGroup(id)
+	if err != nil {
+		return fmt.Errorf("failed to get host group: %w", err)
+	}
+
+	// 检查是否为远程组且设置了刷新间隔
+	if !group.IsFile && (!group.IsRemote || group.RefreshInterval <= 0) {
+		return fmt.Errorf("group is not a remote group or refresh interval is not set")
 	}
 
+	app.rescheduleGroup(id)
+	app.scheduler.start()
 	return nil
 }
 
-// StopAllRemoteGroupRefreshTimers 停止所有远程组的定时刷新
-func (app *HostApp) StopAllRemoteGroupRefreshTimers() {
-	app.mu.Lock()
-	defer app.mu.Unlock()
+// StopRemoteGroupRefreshTimer 将指定分组移出调度
+func (app *HostApp) StopRemoteGroupRefreshTimer(id string) {
+	app.scheduler.remove(id)
+}
 
-	for id := range app.remoteRefreshTimers {
-		app.stopRemoteGroupRefreshTimer(id)
-	}
+// StartAllRemoteGroupRefreshTimers 启动所有需要定时刷新的分组的调度
+func (app *HostApp) StartAllRemoteGroupRefreshTimers() error {
+	return app.StartScheduler()
+}
+
+// StopAllRemoteGroupRefreshTimers 将所有分组移出调度
+func (app *HostApp) StopAllRemoteGroupRefreshTimers() {
+	app.scheduler.removeKind(scheduleKindRemote, scheduleKindFile)
 }
@@ -0,0 +1,161 @@
+package application
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"ghost/models"
+	"ghost/system"
+)
+
+// errElevationRequested 测试中代替提升权限（重新启动应用）的错误
+var errElevationRequested = errors.New("elevation requested")
+
+// fakeHosts 测试用的hosts文件实现：内容读写临时文件，写入权限和提升权限由测试控制并记录
+type fakeHosts struct {
+	*system.HostManager
+
+	mu         sync.Mutex
+	writable   bool
+	writes     int
+	elevations int
+}
+
+// HasWritePermission 返回测试设置的写入权限
+func (f *fakeHosts) HasWritePermission() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writable
+}
+
+// WriteSystemHosts 没有写入权限时返回错误，否则写入临时文件并计数
+func (f *fakeHosts) WriteSystemHosts(content string) error {
+	f.mu.Lock()
+	if !f.writable {
+		f.mu.Unlock()
+		return os.ErrPermission
+	}
+	f.writes++
+	f.mu.Unlock()
+	return f.HostManager.WriteSystemHosts(content)
+}
+
+// RequestElevatedPrivileges 记录一次提升权限请求
+func (f *fakeHosts) RequestElevatedPrivileges() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.elevations++
+	return errElevationRequested
+}
+
+// RequestAdminPrivileges 记录一次提升权限请求
+func (f *fakeHosts) RequestAdminPrivileges() error {
+	return f.RequestElevatedPrivileges()
+}
+
+// setWritable 设置是否有写入权限
+func (f *fakeHosts) setWritable(writable bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writable = writable
+}
+
+// counts 返回写入次数和提升权限请求次数
+func (f *fakeHosts) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writes, f.elevations
+}
+
+// newTestApp 创建使用临时目录保存数据、假hosts文件实现的应用实例
+func newTestApp(t *testing.T) (*HostApp, *fakeHosts) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	app, err := NewHostApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(path, []byte("127.0.0.1 localhost\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hostsFile := &fakeHosts{HostManager: &system.HostManager{SystemHostPath: path}, writable: true}
+	app.hostManager = hostsFile
+	t.Cleanup(app.stopAutoApply)
+	return app, hostsFile
+}
+
+// addTestGroup 添加分组并返回生成的ID
+func addTestGroup(t *testing.T, app *HostApp, group models.HostGroup) string {
+	t.Helper()
+	if err := app.AddHostGroup(group); err != nil {
+		t.Fatal(err)
+	}
+	groups, err := app.GetHostGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return groups[len(groups)-1].ID
+}
+
+// findTestGroup 返回保存的指定分组
+func findTestGroup(t *testing.T, app *HostApp, id string) models.HostGroup {
+	t.Helper()
+	groups, err := app.GetHostGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, group := range groups {
+		if group.ID == id {
+			return group
+		}
+	}
+	t.Fatalf("group %s not found", id)
+	return models.HostGroup{}
+}
+
+// TestEnableHostGroupFor 测试临时启用设置到期时间并安排到期任务，普通切换取消到期时间
+func TestEnableHostGroupFor(t *testing.T) {
+	app, _ := newTestApp(t)
+	id := addTestGroup(t, app, models.HostGroup{Name: "Dev", Content: "10.0.0.1 api.local"})
+	expiryKey := scheduleKey(scheduleKindExpiry, id)
+
+	for _, d := range []time.Duration{0, -time.Minute} {
+		if err := app.EnableHostGroupFor(id, d); err == nil {
+			t.Errorf("expected error for duration %s", d)
+		}
+	}
+	if err := app.EnableHostGroupFor("missing", time.Hour); err == nil {
+		t.Error("expected error for unknown group")
+	}
+
+	before := time.Now()
+	if err := app.EnableHostGroupFor(id, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	group := findTestGroup(t, app, id)
+	expiresAt, err := time.Parse(time.RFC3339, group.ExpiresAt)
+	if !group.Enabled || err != nil || expiresAt.Before(before.Add(time.Hour).Truncate(time.Second)) {
+		t.Errorf("expected group enabled for an hour, got enabled=%t expiresAt=%q", group.Enabled, group.ExpiresAt)
+	}
+	if due, ok := app.scheduler.nextRun(expiryKey); !ok || !due.Equal(expiresAt) {
+		t.Errorf("expected expiry job at %s, got %s (%t)", expiresAt, due, ok)
+	}
+
+	if err := app.ToggleHostGroup(id, true); err != nil {
+		t.Fatal(err)
+	}
+	group = findTestGroup(t, app, id)
+	if !group.Enabled || group.ExpiresAt != "" {
+		t.Errorf("expected toggle to keep the group enabled without expiry, got %+v", group)
+	}
+	if _, ok := app.scheduler.nextRun(expiryKey); ok {
+		t.Error("expected expiry job to be removed")
+	}
+}
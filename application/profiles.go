@@ -0,0 +1,402 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ghost/models"
+)
+
+// ListProfiles 获取所有配置方案
+func (app *HostApp) ListProfiles() ([]models.Profile, error) {
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	if manager.Profiles == nil {
+		return []models.Profile{}, nil
+	}
+	return manager.Profiles, nil
+}
+
+// CreateProfile 添加新的配置方案
+func (app *HostApp) CreateProfile(profile models.Profile) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	profile.ID = uuid.New().String()
+	err = normalizeProfile(manager, &profile)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	profile.CreatedAt = now
+	profile.UpdatedAt = now
+	manager.Profiles = append(manager.Profiles, profile)
+	manager.UpdatedAt = now
+
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return fmt.Errorf("failed to save host manager: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateProfile 更新配置方案，不会切换到该方案
+func (app *HostApp) UpdateProfile(profile models.Profile) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	index := findProfile(manager, func(p *models.Profile) bool { return p.ID == profile.ID })
+	if index < 0 {
+		return fmt.Errorf("profile with ID %s not found", profile.ID)
+	}
+	err = normalizeProfile(manager, &profile)
+	if err != nil {
+		return err
+	}
+
+	oldName := manager.Profiles[index].Name
+	profile.CreatedAt = manager.Profiles[index].CreatedAt
+	profile.UpdatedAt = time.Now().Format(time.RFC3339)
+	manager.Profiles[index] = profile
+	manager.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return fmt.Errorf("failed to save host manager: %w", err)
+	}
+
+	// 当前使用的方案改名时同步更新配置
+	if oldName != profile.Name {
+		app.updateActiveProfile(oldName, profile.Name)
+	}
+
+	return nil
+}
+
+// DeleteProfile 删除配置方案，不会修改分组的启用状态
+func (app *HostApp) DeleteProfile(id string) error {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	index := findProfile(manager, func(p *models.Profile) bool { return p.ID == id })
+	if index < 0 {
+		return fmt.Errorf("profile with ID %s not found", id)
+	}
+
+	name := manager.Profiles[index].Name
+	manager.Profiles = append(manager.Profiles[:index], manager.Profiles[index+1:]...)
+	manager.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return fmt.Errorf("failed to save host manager: %w", err)
+	}
+
+	app.updateActiveProfile(name, "")
+	return nil
+}
+
+// SwitchProfile 切换到指定名称的配置方案
+func (app *HostApp) SwitchProfile(name string) error {
+	return app.SwitchProfileWithResolution(name, "")
+}
+
+// SwitchProfileWithResolution 切换到指定名称的配置方案，resolution指定检测到Ghost段被手动修改时的处理方式
+// 方案中的分组启用、其余分组禁用，并按方案的覆盖设置应用到系统；应用失败或放弃时分组状态、配置和系统hosts文件都不会改变
+func (app *HostApp) SwitchProfileWithResolution(name, resolution string) error {
+	err := app.ensureWritePermission()
+	if err != nil {
+		return err
+	}
+
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return fmt.Errorf("failed to load host manager: %w", err)
+	}
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	index := findProfile(manager, func(p *models.Profile) bool { return p.Name == name })
+	if index < 0 {
+		return fmt.Errorf("profile %s not found", name)
+	}
+	profile := manager.Profiles[index]
+
+	enabled := make(map[string]bool, len(profile.Groups))
+	for _, id := range profile.Groups {
+		enabled[id] = true
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	for i := range manager.Groups {
+		group := &manager.Groups[i]
+		if group.Enabled != enabled[group.ID] {
+			group.Enabled = enabled[group.ID]
+			group.UpdatedAt = now
+		}
+		if !group.Enabled {
+			group.ExpiresAt = ""
+		}
+	}
+
+	// 先保存配置，应用失败或放弃时恢复原有配置，保证分组状态、配置和系统hosts文件一致
+	previous := *config
+	config.ActiveProfile = profile.Name
+	config.ActiveGroups = append([]string{}, profile.Groups...)
+	config.UpdatedAt = now
+	err = app.configStorage.SaveConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	applied, err := app.applyManager(manager, config, resolution)
+	if !applied {
+		if restoreErr := app.configStorage.SaveConfig(&previous); restoreErr != nil {
+			log.Printf("Warning: failed to restore config after profile switch: %v", restoreErr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to switch to profile %s: %w", name, err)
+	}
+	if !applied {
+		return nil
+	}
+
+	log.Printf("Switched to profile %s with %d enabled groups", profile.Name, len(profile.Groups))
+
+	// 分组的启用状态变化后更新刷新和到期调度
+	if app.scheduler.isRunning() {
+		err = app.syncSchedule()
+		if err != nil {
+			log.Printf("Error updating schedule: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ExportProfiles 将所有配置方案导出为JSON
+func (app *HostApp) ExportProfiles() (string, error) {
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return "", fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	names := make(map[string]string, len(manager.Groups))
+	for _, group := range manager.Groups {
+		names[group.ID] = group.Name
+	}
+
+	export := models.ProfileExport{Version: models.ProfileExportVersion, Profiles: []models.ExportProfile{}}
+	for _, profile := range manager.Profiles {
+		exported := models.ExportProfile{
+			Name:        profile.Name,
+			Description: profile.Description,
+			Groups:      []models.ExportGroupRef{},
+			Overrides:   profile.Overrides,
+		}
+		for _, id := range profile.Groups {
+			exported.Groups = append(exported.Groups, models.ExportGroupRef{ID: id, Name: names[id]})
+		}
+		export.Profiles = append(export.Profiles, exported)
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ImportProfiles 导入配置方案，同名方案会被替换，返回导入的数量
+// 分组先按ID匹配，找不到时按名称匹配；任何分组无法匹配时不导入任何方案
+func (app *HostApp) ImportProfiles(data string) (int, error) {
+	var export models.ProfileExport
+	err := json.Unmarshal([]byte(data), &export)
+	if err != nil {
+		return 0, fmt.Errorf("invalid profile export: %w", err)
+	}
+	if export.Version != models.ProfileExportVersion {
+		return 0, fmt.Errorf("unsupported profile export version: %s", export.Version)
+	}
+
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	for _, exported := range export.Profiles {
+		profile := models.Profile{
+			Name:        exported.Name,
+			Description: exported.Description,
+			Overrides:   exported.Overrides,
+		}
+		for _, ref := range exported.Groups {
+			id, err := resolveGroupRef(manager, ref)
+			if err != nil {
+				return 0, fmt.Errorf("profile %s: %w", exported.Name, err)
+			}
+			profile.Groups = append(profile.Groups, id)
+		}
+
+		index := findProfile(manager, func(p *models.Profile) bool { return p.Name == strings.TrimSpace(exported.Name) })
+		if index >= 0 {
+			profile.ID = manager.Profiles[index].ID
+			profile.CreatedAt = manager.Profiles[index].CreatedAt
+		} else {
+			profile.ID = uuid.New().String()
+			profile.CreatedAt = now
+		}
+		profile.UpdatedAt = now
+
+		err = normalizeProfile(manager, &profile)
+		if err != nil {
+			return 0, fmt.Errorf("profile %s: %w", exported.Name, err)
+		}
+		if index >= 0 {
+			manager.Profiles[index] = profile
+		} else {
+			manager.Profiles = append(manager.Profiles, profile)
+		}
+	}
+
+	manager.UpdatedAt = now
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save host manager: %w", err)
+	}
+
+	return len(export.Profiles), nil
+}
+
+// normalizeProfile 校验配置方案，去掉首尾空白和重复的分组，并检查名称唯一和分组存在
+func normalizeProfile(manager *models.HostManager, profile *models.Profile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	err := profile.Validate()
+	if err != nil {
+		return err
+	}
+
+	if findProfile(manager, func(p *models.Profile) bool { return p.Name == profile.Name && p.ID != profile.ID }) >= 0 {
+		return fmt.Errorf("profile %s already exists", profile.Name)
+	}
+
+	exists := make(map[string]bool, len(manager.Groups))
+	for _, group := range manager.Groups {
+		exists[group.ID] = true
+	}
+
+	seen := make(map[string]bool, len(profile.Groups))
+	groups := make([]string, 0, len(profile.Groups))
+	for _, id := range profile.Groups {
+		if !exists[id] {
+			return fmt.Errorf("host group with ID %s not found", id)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		groups = append(groups, id)
+	}
+	profile.Groups = groups
+
+	return nil
+}
+
+// findProfile 返回第一个满足条件的配置方案的下标，找不到时返回-1
+func findProfile(manager *models.HostManager, match func(p *models.Profile) bool) int {
+	for i := range manager.Profiles {
+		if match(&manager.Profiles[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveGroupRef 将导出的分组引用匹配到本地分组，名称匹配到多个分组时视为无法匹配
+func resolveGroupRef(manager *models.HostManager, ref models.ExportGroupRef) (string, error) {
+	var byName []string
+	for _, group := range manager.Groups {
+		if group.ID == ref.ID {
+			return group.ID, nil
+		}
+		if ref.Name != "" && group.Name == ref.Name {
+			byName = append(byName, group.ID)
+		}
+	}
+
+	switch len(byName) {
+	case 1:
+		return byName[0], nil
+	case 0:
+		return "", fmt.Errorf("host group %q not found", ref.Name)
+	default:
+		return "", fmt.Errorf("host group name %q is ambiguous", ref.Name)
+	}
+}
+
+// removeGroupFromProfiles 从所有配置方案中移除被删除的分组
+func removeGroupFromProfiles(manager *models.HostManager, id string) {
+	for i := range manager.Profiles {
+		groups := manager.Profiles[i].Groups[:0]
+		for _, groupID := range manager.Profiles[i].Groups {
+			if groupID != id {
+				groups = append(groups, groupID)
+			}
+		}
+		manager.Profiles[i].Groups = groups
+	}
+}
+
+// updateActiveProfile 当前使用的方案改名或删除后同步更新配置，失败只记录日志，调用时需持有dataMu
+func (app *HostApp) updateActiveProfile(oldName, newName string) {
+	config, err := app.configStorage.LoadConfig()
+	if err != nil {
+		log.Printf("Warning: failed to load config: %v", err)
+		return
+	}
+	if config.ActiveProfile != oldName {
+		return
+	}
+
+	config.ActiveProfile = newName
+	config.UpdatedAt = time.Now().Format(time.RFC3339)
+	err = app.configStorage.SaveConfig(config)
+	if err != nil {
+		log.Printf("Warning: failed to update active profile: %v", err)
+	}
+}
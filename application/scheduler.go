@@ -0,0 +1,448 @@
+package application
+
+import (
+	"container/heap"
+	"context"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"ghost/models"
+)
+
+const (
+	// defaultRefreshWorkers 同时执行的刷新任务数量上限
+	defaultRefreshWorkers = 4
+	// scheduleJitter 刷新间隔的随机抖动比例，避免多个分组同时请求
+	scheduleJitter = 0.1
+	// maxSchedulerSleep 调度器两次检查之间的最长间隔
+	// 系统睡眠期间定时器可能不走，定期按墙上时间检查可以在唤醒后及时补做过期任务
+	maxSchedulerSleep = 30 * time.Second
+	// startupStagger 启动时过期任务的最大随机延迟，避免同时发起所有请求
+	startupStagger = 5 * time.Second
+	// schedulerShutdownTimeout 关闭时等待正在执行的任务的最长时间
+	schedulerShutdownTimeout = 10 * time.Second
+)
+
+const (
+	// scheduleKindRemote 远程分组刷新
+	scheduleKindRemote = "remote"
+	// scheduleKindFile 本地文件分组检查
+	scheduleKindFile = "file"
+	// scheduleKindActivation 按启用计划切换分组
+	scheduleKindActivation = "activation"
+	// scheduleKindExpiry 临时启用的分组到期后禁用
+	scheduleKindExpiry = "expiry"
+)
+
+// scheduleItem 调度器中的一个任务，每个分组的每类任务最多一个
+type scheduleItem struct {
+	key       string
+	groupID   string
+	name      string
+	kind      string
+	interval  time.Duration
+	due       time.Time // 下一次执行时间（墙上时间）
+	lastRun   time.Time
+	running   bool
+	removed   bool      // 执行期间被移除，执行结束后从调度中删除
+	filePath  string    // 仅本地文件分组
+	fileState fileState // 上一次检查时的文件状态，仅本地文件分组
+	index     int       // 在堆中的位置，不在堆中时为-1
+}
+
+// scheduleJob 交给工作goroutine执行的任务，是scheduleItem的副本
+type scheduleJob struct {
+	key       string
+	groupID   string
+	kind      string
+	filePath  string
+	fileState fileState
+}
+
+// scheduleResult 任务执行的结果
+type scheduleResult struct {
+	fileState fileState // 本地文件的最新状态
+	next      time.Time // 指定的下一次执行时间，为零时按间隔和抖动计算
+}
+
+// scheduleKey 返回分组指定类型任务的键，远程刷新和文件检查共用分组ID
+func scheduleKey(kind, groupID string) string {
+	if kind == scheduleKindRemote || kind == scheduleKindFile {
+		return groupID
+	}
+	return groupID + "#" + kind
+}
+
+// scheduleHeap 按下一次执行时间排序的最小堆
+type scheduleHeap []*scheduleItem
+
+func (h scheduleHeap) Len() int           { return len(h) }
+func (h scheduleHeap) Less(i, j int) bool { return h[i].due.Before(h[j].due) }
+func (h scheduleHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *scheduleHeap) Push(x any) {
+	item := x.(*scheduleItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *scheduleHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	item.index = -1
+	*h = old[:len(old)-1]
+	return item
+}
+
+// scheduler 统一调度远程分组刷新和本地文件检查
+// 所有分组保存在按下一次执行时间排序的最小堆中，由固定数量的工作goroutine执行
+type scheduler struct {
+	run     func(job scheduleJob) scheduleResult
+	workers int
+	now     func() time.Time // 当前墙上时间，测试中可以替换
+
+	mu       sync.Mutex
+	items    map[string]*scheduleItem
+	queue    scheduleHeap
+	jobs     chan scheduleJob
+	wake     chan struct{}
+	stop     chan struct{}
+	done     sync.WaitGroup
+	running  bool
+	lastTick time.Time
+	// ctx 在调度器停止时取消，用于中断正在进行的网络请求和重试等待
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newScheduler 创建调度器，run在工作goroutine中执行任务
+func newScheduler(run func(job scheduleJob) scheduleResult, workers int) *scheduler {
+	return &scheduler{
+		run:     run,
+		workers: workers,
+		now:     wallClock,
+		items:   make(map[string]*scheduleItem),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// wallClock 返回去掉单调时钟读数的当前时间，保证比较使用墙上时间
+func wallClock() time.Time {
+	return time.Now().Round(0)
+}
+
+// start 启动调度循环和工作goroutine，已经运行时不做任何事
+func (s *scheduler) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	s.running = true
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.stop = make(chan struct{})
+	s.jobs = make(chan scheduleJob, s.workers)
+	s.lastTick = s.now()
+
+	for i := 0; i < s.workers; i++ {
+		s.done.Add(1)
+		go s.worker(s.stop, s.jobs)
+	}
+	s.done.Add(1)
+	go s.loop(s.stop, s.jobs)
+}
+
+// shutdown 停止调度并等待正在执行的任务完成，超时返回false
+func (s *scheduler) shutdown(timeout time.Duration) bool {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return true
+	}
+	s.running = false
+	close(s.stop)
+	s.cancel()
+	s.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		s.done.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// isRunning 判断调度器是否在运行
+func (s *scheduler) isRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// context 返回后台任务使用的上下文，调度器停止后为已取消的上下文，未启动过时不会取消
+func (s *scheduler) context() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// nextRun 返回任务的下一次执行时间，任务不在调度中或正在执行时返回false
+func (s *scheduler) nextRun(key string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, exists := s.items[key]
+	if !exists || item.running || item.removed {
+		return time.Time{}, false
+	}
+	return item.due, true
+}
+
+// upsert 添加分组或更新已有分组的设置
+// 正在执行的分组只更新设置，执行结束后按新的间隔重新排队
+// 执行期间被移除后又重新添加的分组同样等执行结束后再排队，保证同一任务不会同时执行两次
+func (s *scheduler) upsert(item *scheduleItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item.key = scheduleKey(item.kind, item.groupID)
+	existing, exists := s.items[item.key]
+	if !exists {
+		item.index = -1
+		s.items[item.key] = item
+		heap.Push(&s.queue, item)
+		s.notify()
+		return
+	}
+
+	existing.removed = false
+	existing.name = item.name
+	existing.kind = item.kind
+	existing.interval = item.interval
+	if existing.filePath != item.filePath {
+		existing.filePath = item.filePath
+		existing.fileState = item.fileState
+	}
+	if existing.index >= 0 {
+		existing.due = item.due
+		heap.Fix(&s.queue, existing.index)
+	}
+	s.notify()
+}
+
+// remove 从调度中移除任务，正在执行的任务会执行完但不再排队
+func (s *scheduler) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, exists := s.items[key]
+	if !exists {
+		return
+	}
+	// 正在执行的任务保留到执行结束，期间重新添加时不会再派发一次
+	if item.running {
+		item.removed = true
+		return
+	}
+	delete(s.items, key)
+	if item.index >= 0 {
+		heap.Remove(&s.queue, item.index)
+	}
+}
+
+// removeKind 移除指定类型的所有任务
+func (s *scheduler) removeKind(kinds ...string) {
+	for _, key := range s.keys(kinds...) {
+		s.remove(key)
+	}
+}
+
+// keys 返回当前调度中指定类型的所有任务的键
+func (s *scheduler) keys(kinds ...string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.items))
+	for key, item := range s.items {
+		if !item.removed && slices.Contains(kinds, item.kind) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// snapshot 返回当前的调度计划，按下一次执行时间排序
+func (s *scheduler) snapshot() []models.ScheduleEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]models.ScheduleEntry, 0, len(s.items))
+	for _, item := range s.items {
+		if item.removed {
+			continue
+		}
+		entry := models.ScheduleEntry{
+			GroupID:  item.groupID,
+			Name:     item.name,
+			Kind:     item.kind,
+			Interval: int64(item.interval / time.Second),
+			Running:  item.running,
+		}
+		if !item.running {
+			entry.NextRun = item.due.Format(time.RFC3339)
+		}
+		if !item.lastRun.IsZero() {
+			entry.LastRun = item.lastRun.Format(time.RFC3339)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Running != entries[j].Running {
+			return entries[i].Running
+		}
+		return entries[i].NextRun < entries[j].NextRun
+	})
+	return entries
+}
+
+// notify 唤醒调度循环重新计算等待时间，调用时需持有锁
+func (s *scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop 调度循环：等待到最早的任务到期，把到期任务交给工作goroutine
+func (s *scheduler) loop(stop chan struct{}, jobs chan scheduleJob) {
+	defer s.done.Done()
+	defer close(jobs)
+
+	for {
+		wait := s.dispatch(jobs)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// dispatch 派发所有已到期的任务，返回距下一个任务到期的等待时间
+func (s *scheduler) dispatch(jobs chan scheduleJob) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.now()
+	// 两次检查的墙上时间间隔远超预期，说明系统刚从睡眠中恢复
+	if gap := current.Sub(s.lastTick); gap > 2*maxSchedulerSleep {
+		log.Printf("Scheduler resumed after %s, catching up on overdue groups", gap.Round(time.Second))
+	}
+	s.lastTick = current
+
+	for len(s.queue) > 0 && !s.queue[0].due.After(current) {
+		item := s.queue[0]
+		job := scheduleJob{key: item.key, groupID: item.groupID, kind: item.kind, filePath: item.filePath, fileState: item.fileState}
+
+		// 所有工作goroutine都在忙时留在堆中，等有任务完成后再派发
+		select {
+		case jobs <- job:
+		default:
+			return maxSchedulerSleep
+		}
+
+		heap.Pop(&s.queue)
+		item.running = true
+	}
+
+	if len(s.queue) == 0 {
+		return maxSchedulerSleep
+	}
+	return min(s.queue[0].due.Sub(current), maxSchedulerSleep)
+}
+
+// worker 执行任务，结束后按间隔和抖动重新排队
+func (s *scheduler) worker(stop chan struct{}, jobs chan scheduleJob) {
+	defer s.done.Done()
+
+	for job := range jobs {
+		// 关闭时丢弃尚未开始的任务
+		select {
+		case <-stop:
+			continue
+		default:
+		}
+
+		result := s.run(job)
+		s.finish(job, result)
+	}
+}
+
+// finish 任务结束后更新状态并重新排队；执行期间被移除的任务不再排队
+// 错过的多个周期只补做一次，下一次执行时间从当前时间开始计算
+func (s *scheduler) finish(job scheduleJob, result scheduleResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, exists := s.items[job.key]
+	if !exists || !item.running {
+		return
+	}
+	if item.removed {
+		delete(s.items, job.key)
+		return
+	}
+
+	current := s.now()
+	item.running = false
+	item.lastRun = current
+	if item.filePath == job.filePath {
+		item.fileState = result.fileState
+	}
+	item.due = result.next
+	if item.due.IsZero() {
+		item.due = current.Add(jitter(item.interval))
+	}
+	heap.Push(&s.queue, item)
+	s.notify()
+}
+
+// jitter 在间隔上加上随机抖动
+func jitter(interval time.Duration) time.Duration {
+	spread := time.Duration(float64(interval) * scheduleJitter)
+	if spread <= 0 {
+		return interval
+	}
+	return interval - spread + time.Duration(rand.Int64N(int64(2*spread)+1))
+}
+
+// stagger 返回启动时过期任务的随机延迟
+func stagger() time.Duration {
+	return time.Duration(rand.Int64N(int64(startupStagger)))
+}
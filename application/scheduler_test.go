@@ -0,0 +1,310 @@
+package application
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 测试用的可调时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Now 返回当前的假时间
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance 将假时间向前拨动
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestScheduler 创建使用假时钟、不启动调度循环的调度器，由测试直接调用dispatch和finish
+func newTestScheduler() (*scheduler, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}
+	s := newScheduler(func(scheduleJob) scheduleResult { return scheduleResult{} }, 1)
+	s.now = clock.Now
+	s.lastTick = clock.Now()
+	return s, clock
+}
+
+// dispatchKeys 派发到期任务并返回派发的任务和等待时间
+func dispatchKeys(s *scheduler, capacity int) ([]scheduleJob, time.Duration) {
+	jobs := make(chan scheduleJob, capacity)
+	wait := s.dispatch(jobs)
+	close(jobs)
+
+	var dispatched []scheduleJob
+	for job := range jobs {
+		dispatched = append(dispatched, job)
+	}
+	return dispatched, wait
+}
+
+// jobKeys 返回任务的键列表
+func jobKeys(jobs []scheduleJob) []string {
+	keys := make([]string, len(jobs))
+	for i, job := range jobs {
+		keys[i] = job.key
+	}
+	return keys
+}
+
+// TestSchedulerDispatchOrder 测试按到期时间顺序派发，以及等待时间的计算
+func TestSchedulerDispatchOrder(t *testing.T) {
+	s, clock := newTestScheduler()
+	start := clock.Now()
+	s.upsert(&scheduleItem{groupID: "c", kind: scheduleKindRemote, interval: time.Hour, due: start.Add(3 * time.Second)})
+	s.upsert(&scheduleItem{groupID: "a", kind: scheduleKindRemote, interval: time.Hour, due: start.Add(1 * time.Second)})
+	s.upsert(&scheduleItem{groupID: "b", kind: scheduleKindRemote, interval: time.Hour, due: start.Add(2 * time.Second)})
+
+	jobs, wait := dispatchKeys(s, 10)
+	if len(jobs) != 0 || wait != time.Second {
+		t.Fatalf("expected nothing due and 1s wait, got %v and %s", jobKeys(jobs), wait)
+	}
+
+	clock.Advance(2 * time.Second)
+	jobs, wait = dispatchKeys(s, 10)
+	if got := jobKeys(jobs); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("expected a and b in order, got %v", got)
+	}
+	if wait != time.Second {
+		t.Errorf("expected 1s wait for c, got %s", wait)
+	}
+
+	// 下一个任务很远或没有任务时，最多等待maxSchedulerSleep后按墙上时间重新检查
+	s.upsert(&scheduleItem{groupID: "c", kind: scheduleKindRemote, interval: time.Hour, due: start.Add(time.Hour)})
+	if _, wait = dispatchKeys(s, 10); wait != maxSchedulerSleep {
+		t.Errorf("expected wait capped at %s, got %s", maxSchedulerSleep, wait)
+	}
+	s.remove("c")
+	if _, wait = dispatchKeys(s, 10); wait != maxSchedulerSleep {
+		t.Errorf("expected %s wait with empty queue, got %s", maxSchedulerSleep, wait)
+	}
+}
+
+// TestSchedulerWorkersBusy 测试工作goroutine都在忙时任务留在堆中，稍后再派发
+func TestSchedulerWorkersBusy(t *testing.T) {
+	s, clock := newTestScheduler()
+	s.upsert(&scheduleItem{groupID: "a", kind: scheduleKindRemote, interval: time.Hour, due: clock.Now()})
+	s.upsert(&scheduleItem{groupID: "b", kind: scheduleKindRemote, interval: time.Hour, due: clock.Now()})
+
+	jobs, wait := dispatchKeys(s, 1)
+	if len(jobs) != 1 || wait != maxSchedulerSleep {
+		t.Fatalf("expected one job and %s wait, got %v and %s", maxSchedulerSleep, jobKeys(jobs), wait)
+	}
+	if _, ok := s.nextRun(jobs[0].key); ok {
+		t.Errorf("expected dispatched job to be running")
+	}
+
+	remaining, _ := dispatchKeys(s, 1)
+	if len(remaining) != 1 || remaining[0].key == jobs[0].key {
+		t.Errorf("expected the other job to be dispatched later, got %v", jobKeys(remaining))
+	}
+}
+
+// TestSchedulerUpsertAndRemove 测试更新到期时间、移除未执行的任务，以及执行期间更新设置
+func TestSchedulerUpsertAndRemove(t *testing.T) {
+	s, clock := newTestScheduler()
+	now := clock.Now()
+	s.upsert(&scheduleItem{groupID: "a", kind: scheduleKindRemote, interval: time.Hour, due: now.Add(time.Hour)})
+	s.upsert(&scheduleItem{groupID: "b", kind: scheduleKindRemote, interval: time.Hour, due: now.Add(time.Hour)})
+	s.upsert(&scheduleItem{groupID: "a", kind: scheduleKindActivation, due: now.Add(time.Hour)})
+
+	// 更新已有任务的到期时间会重新排序
+	s.upsert(&scheduleItem{groupID: "a", kind: scheduleKindRemote, interval: time.Minute, due: now})
+	s.remove("b")
+	if next, ok := s.nextRun("b"); ok {
+		t.Errorf("expected removed job to be unscheduled, got %s", next)
+	}
+
+	jobs, _ := dispatchKeys(s, 10)
+	if got := jobKeys(jobs); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("expected only a to be due, got %v", got)
+	}
+
+	// 执行期间修改间隔，结束后按新的间隔排队
+	s.upsert(&scheduleItem{groupID: "a", kind: scheduleKindRemote, interval: 2 * time.Hour, due: now})
+	if again, _ := dispatchKeys(s, 10); len(again) != 0 {
+		t.Errorf("expected running job not to be dispatched again, got %v", jobKeys(again))
+	}
+	s.finish(jobs[0], scheduleResult{})
+	next, ok := s.nextRun("a")
+	if !ok || next.Sub(now) < 2*time.Hour-time.Duration(float64(2*time.Hour)*scheduleJitter) {
+		t.Errorf("expected a to be rescheduled with the new interval, got %s (%t)", next, ok)
+	}
+
+	if keys := s.keys(scheduleKindActivation); len(keys) != 1 || keys[0] != scheduleKey(scheduleKindActivation, "a") {
+		t.Errorf("expected one activation job, got %v", keys)
+	}
+}
+
+// TestSchedulerRemoveDuringRun 测试执行期间移除的任务不再排队，重新添加的任务等执行结束后才会再次派发
+func TestSchedulerRemoveDuringRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		readd  bool
+		queued bool
+	}{
+		{"removed", false, false},
+		{"removed and re-added", true, true},
+	}
+
+	for _, tt := range tests {
+		s, clock := newTestScheduler()
+		item := func() *scheduleItem {
+			return &scheduleItem{groupID: "a", kind: scheduleKindRemote, interval: time.Hour, due: clock.Now()}
+		}
+		s.upsert(item())
+
+		jobs, _ := dispatchKeys(s, 10)
+		if len(jobs) != 1 {
+			t.Fatalf("%s: expected one job, got %v", tt.name, jobKeys(jobs))
+		}
+
+		s.remove("a")
+		if len(s.snapshot()) != 0 {
+			t.Errorf("%s: expected removed job to be hidden from the schedule", tt.name)
+		}
+		if tt.readd {
+			s.upsert(item())
+		}
+		if again, _ := dispatchKeys(s, 10); len(again) != 0 {
+			t.Errorf("%s: expected no second run while the first is running, got %v", tt.name, jobKeys(again))
+		}
+
+		s.finish(jobs[0], scheduleResult{})
+		if _, ok := s.nextRun("a"); ok != tt.queued {
+			t.Errorf("%s: expected queued=%t after finish, got %t", tt.name, tt.queued, ok)
+		}
+		if len(s.snapshot()) != len(s.queue) {
+			t.Errorf("%s: schedule and queue disagree: %d entries, %d queued", tt.name, len(s.snapshot()), len(s.queue))
+		}
+	}
+}
+
+// TestSchedulerFinish 测试执行结束后按指定时间或间隔加抖动重新排队，错过的多个周期只补做一次
+func TestSchedulerFinish(t *testing.T) {
+	s, clock := newTestScheduler()
+	s.upsert(&scheduleItem{groupID: "a", kind: scheduleKindRemote, interval: time.Hour, due: clock.Now()})
+	s.upsert(&scheduleItem{groupID: "a", kind: scheduleKindExpiry, due: clock.Now()})
+
+	// 系统睡眠多个周期后唤醒，每个任务只派发一次
+	clock.Advance(5 * time.Hour)
+	jobs, _ := dispatchKeys(s, 10)
+	if len(jobs) != 2 {
+		t.Fatalf("expected each overdue job once, got %v", jobKeys(jobs))
+	}
+
+	explicit := clock.Now().Add(10 * time.Minute)
+	for _, job := range jobs {
+		result := scheduleResult{}
+		if job.kind == scheduleKindExpiry {
+			result.next = explicit
+		}
+		s.finish(job, result)
+	}
+
+	next, ok := s.nextRun(scheduleKey(scheduleKindExpiry, "a"))
+	if !ok || !next.Equal(explicit) {
+		t.Errorf("expected explicit next run %s, got %s (%t)", explicit, next, ok)
+	}
+	next, ok = s.nextRun("a")
+	if wait := next.Sub(clock.Now()); !ok || wait < 54*time.Minute || wait > 66*time.Minute {
+		t.Errorf("expected next run an interval from now, got %s (%t)", wait, ok)
+	}
+
+	// 未在执行的任务收到结束通知时不做任何事
+	s.finish(scheduleJob{key: "a", groupID: "a", kind: scheduleKindRemote}, scheduleResult{next: explicit})
+	if again, _ := s.nextRun("a"); !again.Equal(next) {
+		t.Errorf("expected stale finish to be ignored, got %s", again)
+	}
+}
+
+// TestJitterAndStagger 测试抖动和启动延迟的范围
+func TestJitterAndStagger(t *testing.T) {
+	interval := time.Hour
+	low := interval - time.Duration(float64(interval)*scheduleJitter)
+	high := interval + time.Duration(float64(interval)*scheduleJitter)
+	for i := 0; i < 1000; i++ {
+		if d := jitter(interval); d < low || d > high {
+			t.Fatalf("jitter %s out of range [%s, %s]", d, low, high)
+		}
+		if d := stagger(); d < 0 || d >= startupStagger {
+			t.Fatalf("stagger %s out of range", d)
+		}
+	}
+	if d := jitter(0); d != 0 {
+		t.Errorf("expected zero interval to stay zero, got %s", d)
+	}
+}
+
+// TestSchedulerNoConcurrentRuns 测试运行中的调度器在任务执行期间移除并重新添加时，同一任务不会同时执行两次
+func TestSchedulerNoConcurrentRuns(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		active  int
+		maxSeen int
+		runs    int
+	)
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	s := newScheduler(func(job scheduleJob) scheduleResult {
+		mu.Lock()
+		active++
+		runs++
+		maxSeen = max(maxSeen, active)
+		mu.Unlock()
+
+		started <- struct{}{}
+		<-release
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return scheduleResult{}
+	}, 2)
+	s.start()
+	defer s.shutdown(time.Second)
+
+	item := func() *scheduleItem {
+		return &scheduleItem{groupID: "a", kind: scheduleKindRemote, interval: time.Hour, due: wallClock()}
+	}
+	s.upsert(item())
+	<-started
+
+	s.remove("a")
+	s.upsert(item())
+
+	select {
+	case <-started:
+		t.Error("re-added job started while the previous run was still running")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := s.nextRun("a"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected re-added job to be queued after the run finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxSeen != 1 || runs != 1 {
+		t.Errorf("expected a single run, got %d runs with up to %d at once", runs, maxSeen)
+	}
+}
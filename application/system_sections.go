@@ -0,0 +1,107 @@
+package application
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ghost/models"
+	"ghost/system"
+)
+
+// GetSystemHostsOrigins 获取系统hosts文件每一行的来源分组
+func (app *HostApp) GetSystemHostsOrigins() ([]models.LineOrigin, error) {
+	content, err := app.hostManager.ReadSystemHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system hosts file: %w", err)
+	}
+
+	origins := system.AttributeLines(content)
+
+	// 标记中的名称可能已过时，使用当前的分组名称
+	groups, err := app.GetHostGroups()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(groups))
+	for _, group := range groups {
+		names[group.ID] = group.Name
+	}
+	for i := range origins {
+		if name, exists := names[origins[i].GroupID]; exists {
+			origins[i].GroupName = name
+		}
+	}
+
+	return origins, nil
+}
+
+// ImportGroupsFromSystemHosts 将系统hosts文件Ghost段中已有但本地不存在的分组导入为本地分组
+// 用于重新安装后恢复之前写入的分组，返回导入的分组数量
+func (app *HostApp) ImportGroupsFromSystemHosts() (int, error) {
+	app.dataMu.Lock()
+	defer app.dataMu.Unlock()
+
+	content, err := app.hostManager.ReadSystemHosts()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read system hosts file: %w", err)
+	}
+
+	manager, err := app.configStorage.LoadHostManager()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load host manager: %w", err)
+	}
+
+	existingIDs := make(map[string]bool, len(manager.Groups))
+	existingNames := make(map[string]bool, len(manager.Groups))
+	for _, group := range manager.Groups {
+		existingIDs[group.ID] = true
+		existingNames[group.Name] = true
+	}
+
+	imported := 0
+	now := time.Now().Format(time.RFC3339)
+	for _, sectionGroup := range system.ParseGhostSection(content) {
+		// 新版本标记以ID识别分组，旧版本标记只能以名称识别
+		if sectionGroup.ID != "" && existingIDs[sectionGroup.ID] {
+			continue
+		}
+		if sectionGroup.ID == "" && existingNames[sectionGroup.Name] {
+			continue
+		}
+
+		id := sectionGroup.ID
+		if id == "" {
+			id = uuid.New().String()
+		}
+
+		manager.Groups = append(manager.Groups, models.HostGroup{
+			ID:          id,
+			Name:        sectionGroup.Name,
+			Description: "Imported from system hosts file",
+			Content:     sectionGroup.Content,
+			Enabled:     true,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+		existingIDs[id] = true
+		existingNames[sectionGroup.Name] = true
+		imported++
+	}
+
+	if imported == 0 {
+		return 0, nil
+	}
+
+	manager.UpdatedAt = now
+	err = app.configStorage.SaveHostManager(manager)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save host manager: %w", err)
+	}
+
+	log.Printf("Imported %d groups from system hosts file", imported)
+
+	return imported, nil
+}
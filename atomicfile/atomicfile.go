@@ -0,0 +1,193 @@
+package atomicfile
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// maxSymlinkDepth 解析符号链接的最大层数，防止循环链接
+const maxSymlinkDepth = 40
+
+// rename 替换目标文件，测试时可以替换以模拟失败
+var rename = os.Rename
+
+// syncDirectory 同步目录，测试时可以替换以模拟失败
+var syncDirectory = syncDir
+
+// WriteFile 以崩溃安全的方式写入文件
+// 内容先写入目标所在目录的临时文件并同步到磁盘，然后通过重命名替换目标文件，
+// 因此任何一步失败都不会破坏原文件。目标为符号链接时写入链接指向的实际文件，
+// 已存在的文件保留原有的权限和属主，新文件使用perm作为权限。
+// 目录不可写或目标无法被替换（如容器中挂载的/etc/hosts）时，退回到直接覆盖写入已存在的文件
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	target, err := ResolveSymlinks(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(target)
+	switch {
+	case err == nil:
+		perm = info.Mode().Perm()
+	case os.IsNotExist(err):
+		info = nil
+	default:
+		return fmt.Errorf("failed to stat %s: %w", target, err)
+	}
+
+	err = replaceFile(target, data, perm, info)
+	if err != nil && info != nil && canFallback(err) {
+		return writeInPlace(target, data)
+	}
+	return err
+}
+
+// CanWrite 判断WriteFile能否写入path：已存在的文件可以直接写入即可，新文件需要所在目录可写
+func CanWrite(path string) bool {
+	target, err := ResolveSymlinks(path)
+	if err != nil {
+		return false
+	}
+
+	file, err := os.OpenFile(target, os.O_WRONLY, 0)
+	if err == nil {
+		file.Close()
+		return true
+	}
+	if !os.IsNotExist(err) {
+		return false
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return false
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+	return true
+}
+
+// canFallback 判断替换文件失败的原因是否是权限或文件被占用，这类情况可以改为直接写入
+func canFallback(err error) bool {
+	return errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EROFS)
+}
+
+// replaceFile 写入临时文件后重命名替换目标文件
+func replaceFile(target string, data []byte, perm os.FileMode, info os.FileInfo) error {
+	dir := filepath.Dir(target)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file in %s: %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+
+	// 重命名成功之前出现任何错误都删除临时文件
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if info != nil {
+		if err := copyOwner(tmpPath, info); err != nil {
+			return fmt.Errorf("failed to preserve file owner: %w", err)
+		}
+	}
+
+	if err := rename(tmpPath, target); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+	renamed = true
+
+	// 同步目录，确保重命名本身也已持久化
+	// 此时目标文件已经被替换，同步失败只影响掉电时的持久性，不能让调用者误以为写入失败而重试或恢复旧内容
+	if err := syncDirectory(dir); err != nil {
+		log.Printf("Warning: replaced %s but failed to sync directory %s: %v", target, dir, err)
+	}
+
+	return nil
+}
+
+// writeInPlace 直接覆盖写入已存在的文件，权限和属主保持不变
+// 这种方式不是崩溃安全的，写入失败时尽量恢复原有内容
+func writeInPlace(target string, data []byte) error {
+	original, err := os.ReadFile(target)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", target, err)
+	}
+
+	file, err := os.OpenFile(target, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", target, err)
+	}
+	defer file.Close()
+
+	err = overwrite(file, data)
+	if err != nil {
+		if restoreErr := overwrite(file, original); restoreErr != nil {
+			return fmt.Errorf("failed to write %s: %w (restoring the original content also failed: %v)", target, err, restoreErr)
+		}
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
+
+// overwrite 从头写入内容并截断多余部分
+func overwrite(file *os.File, data []byte) error {
+	if _, err := file.WriteAt(data, 0); err != nil {
+		return err
+	}
+	if err := file.Truncate(int64(len(data))); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// ResolveSymlinks 逐层解析符号链接，返回最终指向的路径
+// 与filepath.EvalSymlinks不同，链接目标不存在时也能返回其路径
+func ResolveSymlinks(path string) (string, error) {
+	current := path
+	for i := 0; i < maxSymlinkDepth; i++ {
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return current, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to stat %s: %w", current, err)
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return current, nil
+		}
+
+		link, err := os.Readlink(current)
+		if err != nil {
+			return "", fmt.Errorf("failed to read symlink %s: %w", current, err)
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(current), link)
+		}
+		current = link
+	}
+
+	return "", fmt.Errorf("too many levels of symbolic links: %s", path)
+}
@@ -0,0 +1,168 @@
+package atomicfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+// TestWriteFileFollowsSymlink 测试写入符号链接时替换链接指向的文件，链接本身保持不变
+func TestWriteFileFollowsSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require extra privileges on Windows")
+	}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "hosts.real")
+	link := filepath.Join(dir, "hosts")
+	if err := os.WriteFile(target, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("hosts.real", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(link, []byte("new\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink was replaced by a regular file")
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new\n" {
+		t.Errorf("expected symlink target to be updated, got %q", data)
+	}
+}
+
+// TestWriteFilePreservesMode 测试已存在的文件保留原有权限，新文件使用指定的权限
+func TestWriteFilePreservesMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing")
+	if err := os.WriteFile(existing, []byte("old"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(existing, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want os.FileMode
+	}{
+		{existing, 0640},
+		{filepath.Join(dir, "created"), 0600},
+	}
+
+	for _, tt := range tests {
+		if err := WriteFile(tt.path, []byte("new"), 0600); err != nil {
+			t.Fatalf("WriteFile(%s) failed: %v", tt.path, err)
+		}
+		info, err := os.Stat(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != tt.want {
+			t.Errorf("%s: expected mode %o, got %o", tt.path, tt.want, info.Mode().Perm())
+		}
+	}
+}
+
+// TestWriteFileFailureKeepsOriginal 测试替换失败时原文件不变且不留下临时文件
+func TestWriteFileFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	if err := os.WriteFile(path, []byte("original\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	defer func() { rename = os.Rename }()
+
+	if err := WriteFile(path, []byte("changed\n"), 0644); !errors.Is(err, syscall.EXDEV) {
+		t.Fatalf("expected rename error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "original\n" {
+		t.Errorf("original file was modified: %q", data)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected temp file to be removed, found %d entries", len(entries))
+	}
+}
+
+// TestWriteFileFallsBackInPlace 测试文件无法被替换时直接覆盖写入
+func TestWriteFileFallsBackInPlace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	if err := os.WriteFile(path, []byte("a much longer original content\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EBUSY}
+	}
+	defer func() { rename = os.Rename }()
+
+	if err := WriteFile(path, []byte("short\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "short\n" {
+		t.Errorf("expected in-place write, got %q", data)
+	}
+	if !CanWrite(path) {
+		t.Errorf("expected existing writable file to be reported as writable")
+	}
+}
+
+// TestWriteFileIgnoresDirSyncFailure 测试重命名成功后目录同步失败时仍然返回成功
+func TestWriteFileIgnoresDirSyncFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	syncDirectory = func(string) error { return syscall.EIO }
+	defer func() { syncDirectory = syncDir }()
+
+	if err := WriteFile(path, []byte("new\n"), 0644); err != nil {
+		t.Fatalf("expected success after rename, got %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new\n" {
+		t.Errorf("expected replaced content, got %q", data)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+
+package atomicfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// copyOwner 将原文件的属主和属组复制到新文件
+func copyOwner(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if int(stat.Uid) == os.Geteuid() && int(stat.Gid) == os.Getegid() {
+		return nil
+	}
+	return os.Chown(path, int(stat.Uid), int(stat.Gid))
+}
+
+// syncDir 同步目录项到磁盘
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
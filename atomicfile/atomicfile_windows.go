@@ -0,0 +1,15 @@
+//go:build windows
+
+package atomicfile
+
+import "os"
+
+// copyOwner 在Windows上新文件继承目录的ACL，无需额外处理
+func copyOwner(path string, info os.FileInfo) error {
+	return nil
+}
+
+// syncDir Windows不支持同步目录，重命名由文件系统保证持久化
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,130 @@
+package hosts
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// Source 参与冲突分析的一段hosts内容
+type Source struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// Mapping 表示某个来源中主机名到地址的一条映射
+type Mapping struct {
+	SourceID   string `json:"sourceId"`
+	SourceName string `json:"sourceName"`
+	IP         string `json:"ip"`
+	Line       int    `json:"line"`
+}
+
+// Conflict 表示同一主机名被映射到了多个不同地址
+type Conflict struct {
+	Hostname string    `json:"hostname"`
+	Mappings []Mapping `json:"mappings"`
+}
+
+// String 返回冲突的可读描述
+func (c Conflict) String() string {
+	parts := make([]string, len(c.Mappings))
+	for i, m := range c.Mappings {
+		parts[i] = fmt.Sprintf("%s (%s line %d)", m.IP, m.SourceName, m.Line)
+	}
+	return fmt.Sprintf("%s -> %s", c.Hostname, strings.Join(parts, ", "))
+}
+
+// ConflictError 在存在冲突且策略为拒绝时返回
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+// Error 实现error接口
+func (e *ConflictError) Error() string {
+	const maxShown = 5
+	var parts []string
+	for i, conflict := range e.Conflicts {
+		if i >= maxShown {
+			parts = append(parts, fmt.Sprintf("... and %d more", len(e.Conflicts)-maxShown))
+			break
+		}
+		parts = append(parts, conflict.String())
+	}
+	return fmt.Sprintf("%d conflicting hostnames: %s", len(e.Conflicts), strings.Join(parts, "; "))
+}
+
+// FindConflicts 分析所有来源中生效的条目，找出被映射到不同地址的主机名
+// IPv4和IPv6地址分别比较，同一主机名同时拥有一个IPv4和一个IPv6地址不视为冲突
+func FindConflicts(sources []Source) []Conflict {
+	type key struct {
+		hostname string
+		ipv6     bool
+	}
+
+	mappings := make(map[key][]Mapping)
+	var order []key
+
+	for _, source := range sources {
+		for _, entry := range Parse(source.Content).ActiveEntries() {
+			for _, hostname := range entry.Hostnames {
+				k := key{hostname: NormalizeHostname(hostname), ipv6: isIPv6(entry.IP)}
+				if _, exists := mappings[k]; !exists {
+					order = append(order, k)
+				}
+				mappings[k] = append(mappings[k], Mapping{
+					SourceID:   source.ID,
+					SourceName: source.Name,
+					IP:         entry.IP,
+					Line:       entry.Line,
+				})
+			}
+		}
+	}
+
+	var conflicts []Conflict
+	for _, k := range order {
+		list := mappings[k]
+		if !hasDistinctIPs(list) {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{Hostname: k.hostname, Mappings: list})
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].Hostname < conflicts[j].Hostname
+	})
+
+	return conflicts
+}
+
+// NormalizeHostname 将主机名规范化为小写且不带末尾的点
+func NormalizeHostname(hostname string) string {
+	return strings.ToLower(strings.TrimSuffix(hostname, "."))
+}
+
+// isIPv6 判断地址是否为IPv6地址
+func isIPv6(ip string) bool {
+	return strings.Contains(ip, ":")
+}
+
+// hasDistinctIPs 判断映射列表中是否存在不同的地址
+func hasDistinctIPs(list []Mapping) bool {
+	for _, m := range list[1:] {
+		if !sameIP(m.IP, list[0].IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameIP 判断两个地址是否相同（忽略书写形式的差异，如::1与0:0:0:0:0:0:0:1）
+func sameIP(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return a == b
+	}
+	return ipA.Equal(ipB)
+}
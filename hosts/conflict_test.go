@@ -0,0 +1,80 @@
+package hosts
+
+import "testing"
+
+// TestFindConflicts 测试不同来源间主机名冲突的识别
+func TestFindConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []Source
+		want    []string // 冲突的主机名，按字母排序
+	}{
+		{
+			name: "different addresses",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 api.local\n"},
+				{ID: "b", Name: "B", Content: "10.0.0.2 api.local\n"},
+			},
+			want: []string{"api.local"},
+		},
+		{
+			name: "same address is not a conflict",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 api.local\n"},
+				{ID: "b", Name: "B", Content: "10.0.0.1 api.local\n"},
+			},
+		},
+		{
+			name: "equivalent IPv6 spellings",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "::1 api.local\n"},
+				{ID: "b", Name: "B", Content: "0:0:0:0:0:0:0:1 api.local\n"},
+			},
+		},
+		{
+			name: "IPv4 and IPv6 are compared separately",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 api.local\n"},
+				{ID: "b", Name: "B", Content: "fe80::1 api.local\n"},
+			},
+		},
+		{
+			name: "case and trailing dot are ignored",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 API.local.\n"},
+				{ID: "b", Name: "B", Content: "10.0.0.2 api.local\n"},
+			},
+			want: []string{"api.local"},
+		},
+		{
+			name: "disabled entries are ignored",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 api.local\n"},
+				{ID: "b", Name: "B", Content: "# 10.0.0.2 api.local\n"},
+			},
+		},
+		{
+			name: "conflict within one source",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 b.local a.local\n10.0.0.2 b.local a.local\n"},
+			},
+			want: []string{"a.local", "b.local"},
+		},
+	}
+
+	for _, tt := range tests {
+		conflicts := FindConflicts(tt.sources)
+		if len(conflicts) != len(tt.want) {
+			t.Errorf("%s: expected %d conflicts, got %+v", tt.name, len(tt.want), conflicts)
+			continue
+		}
+		for i, conflict := range conflicts {
+			if conflict.Hostname != tt.want[i] {
+				t.Errorf("%s: expected conflict %d on %s, got %s", tt.name, i, tt.want[i], conflict.Hostname)
+			}
+			if len(conflict.Mappings) < 2 {
+				t.Errorf("%s: expected at least 2 mappings for %s, got %+v", tt.name, conflict.Hostname, conflict.Mappings)
+			}
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package hosts
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+const (
+	// FormatHosts 标准hosts文件（默认）
+	FormatHosts = "hosts"
+	// FormatDomains 每行一个域名的列表
+	FormatDomains = "domains"
+	// FormatAdblock adblock语法（||example.com^）
+	FormatAdblock = "adblock"
+	// FormatDnsmasq dnsmasq配置（address=/example.com/0.0.0.0）
+	FormatDnsmasq = "dnsmasq"
+	// FormatRPZ DNS响应策略区域（RPZ）文件
+	FormatRPZ = "rpz"
+)
+
+// DefaultSinkAddress 转换屏蔽列表时默认使用的地址
+const DefaultSinkAddress = "0.0.0.0"
+
+// ValidateFormat 校验来源格式和屏蔽地址
+func ValidateFormat(format, sink string) error {
+	switch format {
+	case "", FormatHosts, FormatDomains, FormatAdblock, FormatDnsmasq, FormatRPZ:
+	default:
+		return fmt.Errorf("unsupported source format: %s", format)
+	}
+	if sink != "" && !IsIP(sink) {
+		return fmt.Errorf("invalid sink address: %s", sink)
+	}
+	return nil
+}
+
+// Convert 将指定格式的列表转换为hosts内容，屏蔽类规则映射到sink地址
+// 输出与输入逐行对应：注释原样保留为hosts注释，无法表示为hosts条目的行以注释形式保留并作为问题返回
+func Convert(content, format, sink string) (string, []Issue, error) {
+	if err := ValidateFormat(format, sink); err != nil {
+		return "", nil, err
+	}
+	if format == "" || format == FormatHosts {
+		return content, nil, nil
+	}
+	if LooksLikeHTML(content) {
+		return "", nil, ErrNotHostsContent
+	}
+	if sink == "" {
+		sink = DefaultSinkAddress
+	}
+
+	var convertLine func(text string) (ip string, hostnames []string, reason string)
+	switch format {
+	case FormatDomains:
+		convertLine = convertDomainLine
+	case FormatAdblock:
+		convertLine = convertAdblockLine
+	case FormatDnsmasq:
+		convertLine = convertDnsmasqLine
+	case FormatRPZ:
+		convertLine = (&rpzState{}).convertLine
+	}
+
+	rawLines := strings.Split(content, "\n")
+	out := make([]string, len(rawLines))
+	var issues []Issue
+
+	for i, raw := range rawLines {
+		text := strings.TrimSpace(strings.TrimSuffix(raw, "\r"))
+		if text == "" {
+			continue
+		}
+		if comment, ok := commentText(text, format); ok {
+			out[i] = "# " + comment
+			continue
+		}
+
+		ip, hostnames, reason := convertLine(text)
+		switch {
+		case reason != "":
+			issues = append(issues, Issue{Line: i + 1, Text: raw, Reason: reason})
+			out[i] = "# " + text
+		case len(hostnames) == 0:
+			// 指令等不产生条目的行
+			out[i] = "# " + text
+		default:
+			if ip == "" {
+				ip = sink
+			}
+			out[i] = Entry{IP: ip, Hostnames: hostnames}.String()
+		}
+	}
+
+	return strings.Join(out, "\n"), issues, nil
+}
+
+// MergeIssues 合并两组问题并按行号排序
+func MergeIssues(a, b []Issue) []Issue {
+	if len(a) == 0 {
+		return b
+	}
+	merged := append(append([]Issue{}, a...), b...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Line < merged[j].Line })
+	return merged
+}
+
+// commentText 判断一行是否为该格式的注释，返回去掉注释符号后的内容
+func commentText(text, format string) (string, bool) {
+	var prefixes []string
+	switch format {
+	case FormatAdblock:
+		prefixes = []string{"!", "#", "["}
+	case FormatRPZ:
+		prefixes = []string{";", "#"}
+	default:
+		prefixes = []string{"#"}
+	}
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(text, prefix) {
+			// adblock中的##和#@#是元素隐藏规则，不是注释
+			if format == FormatAdblock && (strings.HasPrefix(text, "##") || strings.HasPrefix(text, "#@#")) {
+				return "", false
+			}
+			// adblock的[Adblock Plus 2.0]头部整行保留
+			if prefix == "[" {
+				return text, true
+			}
+			return strings.TrimSpace(strings.TrimPrefix(text, prefix)), true
+		}
+	}
+	return "", false
+}
+
+// convertDomainLine 转换纯域名列表中的一行
+func convertDomainLine(text string) (string, []string, string) {
+	if idx := strings.Index(text, "#"); idx >= 0 {
+		text = strings.TrimSpace(text[:idx])
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) != 1 {
+		return "", nil, "expected a single domain"
+	}
+	return checkDomain(fields[0])
+}
+
+// convertAdblockLine 转换adblock规则，只支持不带选项的域名屏蔽规则
+func convertAdblockLine(text string) (string, []string, string) {
+	switch {
+	case strings.HasPrefix(text, "@@"):
+		return "", nil, "exception rules cannot be expressed in hosts"
+	case strings.Contains(text, "##") || strings.Contains(text, "#@#") || strings.Contains(text, "#?#"):
+		return "", nil, "cosmetic rules cannot be expressed in hosts"
+	}
+
+	rule := text
+	if idx := strings.Index(rule, "$"); idx >= 0 {
+		for _, option := range strings.Split(rule[idx+1:], ",") {
+			if option != "important" && option != "all" {
+				return "", nil, fmt.Sprintf("rule option %q cannot be expressed in hosts", option)
+			}
+		}
+		rule = rule[:idx]
+	}
+
+	if !strings.HasPrefix(rule, "||") {
+		return "", nil, "only ||domain^ rules can be expressed in hosts"
+	}
+	domain := strings.TrimSuffix(strings.TrimPrefix(rule, "||"), "^")
+	if strings.ContainsAny(domain, "/^|") {
+		return "", nil, "rules with paths cannot be expressed in hosts"
+	}
+
+	return checkDomain(domain)
+}
+
+// convertDnsmasqLine 转换dnsmasq的address=行（dnsmasq不支持行内注释，#表示屏蔽）
+// 地址为空、#、0.0.0.0或::时使用sink，其他地址（包括回环地址）保留原样
+func convertDnsmasqLine(text string) (string, []string, string) {
+	key, value, found := strings.Cut(text, "=")
+	if !found {
+		return "", nil, "not a dnsmasq option"
+	}
+	key = strings.TrimSpace(key)
+	if key != "address" {
+		return "", nil, fmt.Sprintf("dnsmasq option %q cannot be expressed in hosts", key)
+	}
+
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) < 3 || parts[0] != "" {
+		return "", nil, "malformed address option"
+	}
+	domains, target := parts[1:len(parts)-1], parts[len(parts)-1]
+
+	ip := ""
+	if target != "" && target != "#" && !isSinkAddress(target) {
+		if !IsIP(target) {
+			return "", nil, fmt.Sprintf("invalid address %q", target)
+		}
+		ip = target
+	}
+
+	var hostnames []string
+	for _, domain := range domains {
+		_, names, reason := checkDomain(domain)
+		if reason != "" {
+			return "", nil, reason
+		}
+		hostnames = append(hostnames, names...)
+	}
+	return ip, hostnames, ""
+}
+
+// rpzState 保存解析RPZ区域文件时的上下文
+type rpzState struct {
+	origin   string // 当前$ORIGIN（小写，不带首尾的点）
+	inParens bool   // 是否处于跨行记录（如SOA）的括号中
+	owner    string // 上一条记录的所有者名
+}
+
+// rrTypes 常见的资源记录类型，用于识别省略了所有者名的记录
+var rrTypes = map[string]bool{
+	"A": true, "AAAA": true, "CNAME": true, "NS": true, "SOA": true,
+	"TXT": true, "MX": true, "PTR": true, "SRV": true, "DNAME": true,
+}
+
+// convertLine 转换RPZ区域文件中的一行
+// 支持CNAME .（NXDOMAIN）、CNAME *.（NODATA）以及A/AAAA记录
+func (s *rpzState) convertLine(text string) (string, []string, string) {
+	if idx := strings.Index(text, ";"); idx >= 0 {
+		text = strings.TrimSpace(text[:idx])
+	}
+
+	// 跨行记录只出现在SOA等不需要转换的记录中，只解析第一行以获取所有者名
+	if s.inParens {
+		if strings.Contains(text, ")") {
+			s.inParens = false
+		}
+		return "", nil, ""
+	}
+	if idx := strings.Index(text, "("); idx >= 0 && !strings.Contains(text, ")") {
+		s.inParens = true
+		text = text[:idx]
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", nil, ""
+	}
+	if strings.HasPrefix(fields[0], "$") {
+		if strings.EqualFold(fields[0], "$ORIGIN") && len(fields) > 1 {
+			s.origin = strings.Trim(strings.ToLower(fields[1]), ".")
+		}
+		return "", nil, ""
+	}
+	// 省略所有者名的记录沿用上一条记录的所有者
+	owner, rest := fields[0], fields[1:]
+	if isNumber(owner) || strings.EqualFold(owner, "IN") || rrTypes[strings.ToUpper(owner)] {
+		owner, rest = s.owner, fields
+	}
+	s.owner = owner
+
+	// 跳过TTL和类别
+	for len(rest) > 0 && (isNumber(rest[0]) || strings.EqualFold(rest[0], "IN")) {
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return "", nil, "incomplete resource record"
+	}
+	recordType := strings.ToUpper(rest[0])
+	if recordType == "SOA" || recordType == "NS" {
+		// 没有$ORIGIN时以SOA记录的所有者作为区域名，用于去掉绝对名称中的区域后缀
+		if recordType == "SOA" && s.origin == "" && strings.HasSuffix(owner, ".") {
+			s.origin = strings.Trim(strings.ToLower(owner), ".")
+		}
+		return "", nil, ""
+	}
+	if len(rest) < 2 {
+		return "", nil, "incomplete resource record"
+	}
+	target := rest[1]
+
+	switch recordType {
+	case "CNAME":
+		if target != "." && target != "*." {
+			return "", nil, fmt.Sprintf("CNAME target %q cannot be expressed in hosts", target)
+		}
+		target = ""
+	case "A", "AAAA":
+		if !IsIP(target) {
+			return "", nil, fmt.Sprintf("invalid address %q", target)
+		}
+		if isSinkAddress(target) {
+			target = ""
+		}
+	default:
+		return "", nil, fmt.Sprintf("record type %s cannot be expressed in hosts", recordType)
+	}
+
+	domain := s.triggerName(owner)
+	if strings.HasPrefix(domain, "*.") {
+		return "", nil, "wildcard triggers cannot be expressed in hosts"
+	}
+	for _, suffix := range []string{".rpz-ip", ".rpz-nsip", ".rpz-nsdname", ".rpz-client-ip"} {
+		if strings.HasSuffix(strings.ToLower(domain), suffix) {
+			return "", nil, fmt.Sprintf("%s triggers cannot be expressed in hosts", strings.TrimPrefix(suffix, "."))
+		}
+	}
+	_, hostnames, reason := checkDomain(domain)
+	return target, hostnames, reason
+}
+
+// triggerName 将记录的所有者名转换为被匹配的域名：去掉区域名后缀
+func (s *rpzState) triggerName(owner string) string {
+	if !strings.HasSuffix(owner, ".") {
+		return owner
+	}
+	name := strings.TrimSuffix(strings.ToLower(owner), ".")
+	if s.origin != "" {
+		name = strings.TrimSuffix(name, "."+s.origin)
+	}
+	return name
+}
+
+// checkDomain 校验域名，通配符和非法域名返回原因
+func checkDomain(domain string) (string, []string, string) {
+	if strings.Contains(domain, "*") {
+		return "", nil, "wildcard domains cannot be expressed in hosts"
+	}
+	if reason := ValidateHostname(domain); reason != "" {
+		return "", nil, reason
+	}
+	return "", []string{domain}, ""
+}
+
+// isSinkAddress 判断地址是否为表示屏蔽的未指定地址（0.0.0.0、::）
+// 127.0.0.1等回环地址可能是有意的重定向（如本地开发域名），按原样保留
+func isSinkAddress(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.IsUnspecified()
+}
+
+// isNumber 判断字符串是否只包含数字
+func isNumber(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
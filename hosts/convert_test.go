@@ -0,0 +1,138 @@
+package hosts
+
+import "testing"
+
+// TestConvert 测试各来源格式转换为hosts内容，包括屏蔽地址的替换和无法表示的规则
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		sink    string
+		input   string
+		want    string
+		issues  int
+		wantErr bool
+	}{
+		{
+			name:   "hosts passthrough",
+			format: FormatHosts,
+			input:  "127.0.0.1 a.test\n",
+			want:   "127.0.0.1 a.test\n",
+		},
+		{
+			name:   "domains",
+			format: FormatDomains,
+			input:  "# list\nads.example.com\nbad domain\n",
+			want:   "# list\n0.0.0.0 ads.example.com\n# bad domain\n",
+			issues: 1,
+		},
+		{
+			name:   "domains custom sink",
+			format: FormatDomains,
+			sink:   "::",
+			input:  "ads.example.com",
+			want:   ":: ads.example.com",
+		},
+		{
+			name:   "adblock",
+			format: FormatAdblock,
+			input:  "[Adblock Plus 2.0]\n! comment\n||ads.example.com^\n||track.example.com^$important\n@@||ok.example.com^\n##.banner\n||cdn.example.com^$script\n",
+			want:   "# [Adblock Plus 2.0]\n# comment\n0.0.0.0 ads.example.com\n0.0.0.0 track.example.com\n# @@||ok.example.com^\n# ##.banner\n# ||cdn.example.com^$script\n",
+			issues: 3,
+		},
+		{
+			name:   "dnsmasq",
+			format: FormatDnsmasq,
+			input: "address=/ads.example.com/0.0.0.0\n" +
+				"address=/v6.example.com/::\n" +
+				"address=/hash.example.com/#\n" +
+				"address=/empty.example.com/\n" +
+				"address=/dev.test/127.0.0.1\n" +
+				"address=/lan.test/192.168.1.10\n" +
+				"server=/corp.example/10.0.0.1\n",
+			want: "0.0.0.0 ads.example.com\n" +
+				"0.0.0.0 v6.example.com\n" +
+				"0.0.0.0 hash.example.com\n" +
+				"0.0.0.0 empty.example.com\n" +
+				"127.0.0.1 dev.test\n" +
+				"192.168.1.10 lan.test\n" +
+				"# server=/corp.example/10.0.0.1\n",
+			issues: 1,
+		},
+		{
+			name:   "rpz with origin",
+			format: FormatRPZ,
+			input: "$TTL 300\n" +
+				"$ORIGIN rpz.example.\n" +
+				"@ IN SOA localhost. root.localhost. (\n" +
+				"  1 3600 600 86400 300 )\n" +
+				"  IN NS localhost.\n" +
+				"ads.example.com CNAME .\n" +
+				"abs.example.com.rpz.example. CNAME *.\n" +
+				"dev.test A 127.0.0.1\n" +
+				"sink.test A 0.0.0.0\n" +
+				"*.wild.example.com CNAME .\n" +
+				"32.1.0.0.10.rpz-ip CNAME .\n" +
+				"other.example.com CNAME walled.garden.\n",
+			want: "# $TTL 300\n" +
+				"# $ORIGIN rpz.example.\n" +
+				"# @ IN SOA localhost. root.localhost. (\n" +
+				"# 1 3600 600 86400 300 )\n" +
+				"# IN NS localhost.\n" +
+				"0.0.0.0 ads.example.com\n" +
+				"0.0.0.0 abs.example.com\n" +
+				"127.0.0.1 dev.test\n" +
+				"0.0.0.0 sink.test\n" +
+				"# *.wild.example.com CNAME .\n" +
+				"# 32.1.0.0.10.rpz-ip CNAME .\n" +
+				"# other.example.com CNAME walled.garden.\n",
+			issues: 3,
+		},
+		{
+			name:   "rpz absolute names without origin",
+			format: FormatRPZ,
+			input: "rpz.example. 300 IN SOA localhost. root.localhost. 1 3600 600 86400 300\n" +
+				"bar.com.rpz.example. CNAME .\n" +
+				"baz.com.rpz.example. 300 IN A 10.0.0.1\n",
+			want: "# rpz.example. 300 IN SOA localhost. root.localhost. 1 3600 600 86400 300\n" +
+				"0.0.0.0 bar.com\n" +
+				"10.0.0.1 baz.com\n",
+		},
+		{
+			name:    "html is rejected",
+			format:  FormatDomains,
+			input:   "<!DOCTYPE html><html><body>login</body></html>",
+			wantErr: true,
+		},
+		{
+			name:    "invalid sink",
+			format:  FormatDomains,
+			sink:    "blocked",
+			input:   "ads.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			format:  "pac",
+			input:   "ads.example.com",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, issues, err := Convert(tt.input, tt.format, tt.sink)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error=%t, got %v", tt.name, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: output mismatch:\ngot:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+		if len(issues) != tt.issues {
+			t.Errorf("%s: expected %d issues, got %d: %+v", tt.name, tt.issues, len(issues), issues)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package hosts
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// DefaultDiffContext 统一diff格式默认的上下文行数
+const DefaultDiffContext = 3
+
+// EntryChange 表示一个主机名映射的变化
+type EntryChange struct {
+	Hostname string   `json:"hostname"`
+	OldIPs   []string `json:"oldIps,omitempty"`
+	NewIPs   []string `json:"newIps,omitempty"`
+}
+
+// EntryChanges 两份hosts内容之间条目级别的差异
+type EntryChanges struct {
+	Added   []EntryChange `json:"added"`
+	Removed []EntryChange `json:"removed"`
+	Changed []EntryChange `json:"changed"`
+}
+
+// DiffEntries 比较两份hosts内容中生效的条目，按主机名统计新增、删除和地址变化
+func DiffEntries(oldContent, newContent string) EntryChanges {
+	oldMap := hostnameIPs(oldContent)
+	newMap := hostnameIPs(newContent)
+
+	var changes EntryChanges
+	for hostname, newIPs := range newMap {
+		oldIPs, exists := oldMap[hostname]
+		switch {
+		case !exists:
+			changes.Added = append(changes.Added, EntryChange{Hostname: hostname, NewIPs: newIPs})
+		case strings.Join(oldIPs, ",") != strings.Join(newIPs, ","):
+			changes.Changed = append(changes.Changed, EntryChange{Hostname: hostname, OldIPs: oldIPs, NewIPs: newIPs})
+		}
+	}
+	for hostname, oldIPs := range oldMap {
+		if _, exists := newMap[hostname]; !exists {
+			changes.Removed = append(changes.Removed, EntryChange{Hostname: hostname, OldIPs: oldIPs})
+		}
+	}
+
+	for _, list := range [][]EntryChange{changes.Added, changes.Removed, changes.Changed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Hostname < list[j].Hostname })
+	}
+
+	return changes
+}
+
+// hostnameIPs 返回每个主机名对应的去重且排序后的地址列表
+func hostnameIPs(content string) map[string][]string {
+	sets := make(map[string]map[string]bool)
+	for _, entry := range Parse(content).ActiveEntries() {
+		ip := entry.IP
+		if parsed := net.ParseIP(ip); parsed != nil {
+			ip = parsed.String()
+		}
+		for _, hostname := range entry.Hostnames {
+			name := NormalizeHostname(hostname)
+			if sets[name] == nil {
+				sets[name] = make(map[string]bool)
+			}
+			sets[name][ip] = true
+		}
+	}
+
+	result := make(map[string][]string, len(sets))
+	for name, set := range sets {
+		ips := make([]string, 0, len(set))
+		for ip := range set {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+		result[name] = ips
+	}
+	return result
+}
+
+// UnifiedDiff 生成两段文本之间的统一diff格式差异，内容相同时返回空字符串
+func UnifiedDiff(oldName, newName, oldText, newText string, context int) string {
+	if oldText == newText {
+		return ""
+	}
+
+	a := splitLines(oldText)
+	b := splitLines(newText)
+	removed, added := diffLines(a, b)
+
+	// 将差异标记展开为按顺序排列的操作
+	type op struct {
+		kind byte // ' '、'-'、'+'
+		text string
+		i, j int // 操作前在a和b中的位置
+	}
+	var ops []op
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && removed[i]:
+			ops = append(ops, op{'-', a[i], i, j})
+			i++
+		case j < len(b) && added[j]:
+			ops = append(ops, op{'+', b[j], i, j})
+			j++
+		default:
+			ops = append(ops, op{' ', a[i], i, j})
+			i++
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+
+	// 将相距不超过两倍上下文的变化合并到同一个hunk中
+	var changed []int
+	for idx, o := range ops {
+		if o.kind != ' ' {
+			changed = append(changed, idx)
+		}
+	}
+
+	for g := 0; g < len(changed); {
+		first, last := changed[g], changed[g]
+		g++
+		for g < len(changed) && changed[g]-last-1 <= 2*context {
+			last = changed[g]
+			g++
+		}
+
+		hunkStart := max(first-context, 0)
+		hunkEnd := min(last+1+context, len(ops))
+
+		var oldCount, newCount int
+		for _, o := range ops[hunkStart:hunkEnd] {
+			if o.kind != '+' {
+				oldCount++
+			}
+			if o.kind != '-' {
+				newCount++
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(ops[hunkStart].i, oldCount), hunkRange(ops[hunkStart].j, newCount)))
+		for _, o := range ops[hunkStart:hunkEnd] {
+			sb.WriteByte(o.kind)
+			sb.WriteString(o.text)
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// hunkRange 生成hunk头中的行范围，start为从0开始的位置
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+// splitLines 将文本按行拆分，忽略末尾换行符产生的空行
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines 使用线性空间的Myers算法计算最短编辑脚本
+// 返回a中被删除的行和b中新增的行的标记
+func diffLines(a, b []string) ([]bool, []bool) {
+	// 将行内容映射为整数，加快比较
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			id, exists := ids[line]
+			if !exists {
+				id = len(ids)
+				ids[line] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+
+	d := &differ{
+		a:       intern(a),
+		b:       intern(b),
+		removed: make([]bool, len(a)),
+		added:   make([]bool, len(b)),
+	}
+	d.compare(0, len(a), 0, len(b))
+
+	return d.removed, d.added
+}
+
+// differ 保存Myers算法的输入和结果
+type differ struct {
+	a, b    []int
+	removed []bool
+	added   []bool
+}
+
+// compare 比较a[xlo:xhi]和b[ylo:yhi]，记录变化的行
+func (d *differ) compare(xlo, xhi, ylo, yhi int) {
+	// 去掉公共前缀和后缀
+	for xlo < xhi && ylo < yhi && d.a[xlo] == d.b[ylo] {
+		xlo++
+		ylo++
+	}
+	for xlo < xhi && ylo < yhi && d.a[xhi-1] == d.b[yhi-1] {
+		xhi--
+		yhi--
+	}
+
+	switch {
+	case xlo == xhi:
+		for j := ylo; j < yhi; j++ {
+			d.added[j] = true
+		}
+	case ylo == yhi:
+		for i := xlo; i < xhi; i++ {
+			d.removed[i] = true
+		}
+	default:
+		xs, ys, xe, ye := d.middleSnake(xlo, xhi, ylo, yhi)
+		d.compare(xlo, xs, ylo, ys)
+		d.compare(xe, xhi, ye, yhi)
+	}
+}
+
+// middleSnake 找到最短编辑路径中间的一段对角线（snake），返回其起点和终点的绝对坐标
+func (d *differ) middleSnake(xlo, xhi, ylo, yhi int) (int, int, int, int) {
+	n, m := xhi-xlo, yhi-ylo
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+
+	vf := make([]int, 2*limit+3)
+	vb := make([]int, 2*limit+3)
+
+	for step := 0; step <= limit; step++ {
+		// 正向搜索
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			xs, ys := x, y
+			for x < n && y < m && d.a[xlo+x] == d.b[ylo+y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+
+			c := delta - k
+			if odd && c >= -(step-1) && c <= step-1 && x+vb[offset+c] >= n {
+				return xlo + xs, ylo + ys, xlo + x, ylo + y
+			}
+		}
+
+		// 反向搜索（在反转的序列上进行）
+		for c := -step; c <= step; c += 2 {
+			var x int
+			if c == -step || (c != step && vb[offset+c-1] < vb[offset+c+1]) {
+				x = vb[offset+c+1]
+			} else {
+				x = vb[offset+c-1] + 1
+			}
+			y := x - c
+			xs, ys := x, y
+			for x < n && y < m && d.a[xhi-1-x] == d.b[yhi-1-y] {
+				x++
+				y++
+			}
+			vb[offset+c] = x
+
+			k := delta - c
+			if !odd && k >= -step && k <= step && x+vf[offset+k] >= n {
+				return xhi - x, yhi - y, xhi - xs, yhi - ys
+			}
+		}
+	}
+
+	// 理论上不会到达这里，退化为整体替换
+	return xhi, ylo, xhi, ylo
+}
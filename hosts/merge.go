@@ -0,0 +1,93 @@
+package hosts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resolution 记录一个被多个来源争用的主机名最终由哪个来源胜出
+type Resolution struct {
+	Hostname string    `json:"hostname"`
+	Winner   Mapping   `json:"winner"`
+	Losers   []Mapping `json:"losers"`
+}
+
+// String 返回可写入hosts文件注释的描述
+func (r Resolution) String() string {
+	losers := make([]string, len(r.Losers))
+	for i, m := range r.Losers {
+		losers[i] = fmt.Sprintf("%s %s", m.SourceName, m.IP)
+	}
+	return fmt.Sprintf("%s -> %s from %s (overrides: %s)",
+		r.Hostname, r.Winner.IP, r.Winner.SourceName, strings.Join(losers, ", "))
+}
+
+// Dedupe 按来源顺序合并内容，每个主机名（按IPv4/IPv6分别计算）只保留最先出现的映射
+// 返回与sources一一对应的去重后内容，以及被争用主机名的裁决结果
+func Dedupe(sources []Source) ([]string, []Resolution) {
+	type key struct {
+		hostname string
+		ipv6     bool
+	}
+
+	winners := make(map[key]Mapping)
+	resolutions := make(map[key]*Resolution)
+	var contested []key
+
+	contents := make([]string, len(sources))
+	for i, source := range sources {
+		file := Parse(source.Content)
+		kept := make([]Line, 0, len(file.Lines))
+
+		for _, line := range file.Lines {
+			if line.Kind != LineEntry || line.Entry.Disabled {
+				kept = append(kept, line)
+				continue
+			}
+
+			entry := *line.Entry
+			var remaining []string
+			for _, hostname := range entry.Hostnames {
+				k := key{hostname: NormalizeHostname(hostname), ipv6: isIPv6(entry.IP)}
+				mapping := Mapping{SourceID: source.ID, SourceName: source.Name, IP: entry.IP, Line: entry.Line}
+
+				winner, claimed := winners[k]
+				if !claimed {
+					winners[k] = mapping
+					remaining = append(remaining, hostname)
+					continue
+				}
+
+				// 相同地址的重复映射直接去掉，不同地址才记录为争用
+				if sameIP(winner.IP, entry.IP) {
+					continue
+				}
+				if _, exists := resolutions[k]; !exists {
+					resolutions[k] = &Resolution{Hostname: k.hostname, Winner: winner}
+					contested = append(contested, k)
+				}
+				resolutions[k].Losers = append(resolutions[k].Losers, mapping)
+			}
+
+			switch {
+			case len(remaining) == len(entry.Hostnames):
+				kept = append(kept, line)
+			case len(remaining) > 0:
+				entry.Hostnames = remaining
+				line.Raw = entry.String()
+				line.Entry = &entry
+				kept = append(kept, line)
+			}
+		}
+
+		file.Lines = kept
+		contents[i] = file.String()
+	}
+
+	result := make([]Resolution, 0, len(contested))
+	for _, k := range contested {
+		result = append(result, *resolutions[k])
+	}
+
+	return contents, result
+}
@@ -0,0 +1,77 @@
+package hosts
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDedupe 测试按来源顺序去重：先出现的映射胜出，相同地址的重复映射直接去掉
+func TestDedupe(t *testing.T) {
+	tests := []struct {
+		name      string
+		sources   []Source
+		want      []string
+		contested []string
+	}{
+		{
+			name: "first source wins",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 api.local\n"},
+				{ID: "b", Name: "B", Content: "10.0.0.2 api.local\n10.0.0.3 other.local\n"},
+			},
+			want:      []string{"10.0.0.1 api.local\n", "10.0.0.3 other.local\n"},
+			contested: []string{"api.local"},
+		},
+		{
+			name: "duplicates with the same address are removed silently",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 api.local\n"},
+				{ID: "b", Name: "B", Content: "10.0.0.1 api.local\n"},
+			},
+			want: []string{"10.0.0.1 api.local\n", ""},
+		},
+		{
+			name: "only contested hostnames are removed from a line",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 api.local\n"},
+				{ID: "b", Name: "B", Content: "10.0.0.2 web.local api.local # both\n"},
+			},
+			want:      []string{"10.0.0.1 api.local\n", "10.0.0.2 web.local # both\n"},
+			contested: []string{"api.local"},
+		},
+		{
+			name: "comments and disabled entries are kept",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 api.local\n"},
+				{ID: "b", Name: "B", Content: "# note\n# 10.0.0.2 api.local\n"},
+			},
+			want: []string{"10.0.0.1 api.local\n", "# note\n# 10.0.0.2 api.local\n"},
+		},
+		{
+			name: "IPv4 and IPv6 are deduplicated separately",
+			sources: []Source{
+				{ID: "a", Name: "A", Content: "10.0.0.1 api.local\n"},
+				{ID: "b", Name: "B", Content: "fe80::1 api.local\n"},
+			},
+			want: []string{"10.0.0.1 api.local\n", "fe80::1 api.local\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		contents, resolutions := Dedupe(tt.sources)
+		if !reflect.DeepEqual(contents, tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, contents)
+		}
+
+		var contested []string
+		for _, resolution := range resolutions {
+			contested = append(contested, resolution.Hostname)
+			if resolution.Winner.SourceID != tt.sources[0].ID || len(resolution.Losers) == 0 {
+				t.Errorf("%s: unexpected resolution %+v", tt.name, resolution)
+			}
+		}
+		if !reflect.DeepEqual(contested, tt.contested) {
+			t.Errorf("%s: expected contested %v, got %v", tt.name, tt.contested, contested)
+		}
+	}
+}
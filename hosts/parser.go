@@ -0,0 +1,161 @@
+package hosts
+
+import (
+	"net"
+	"strings"
+)
+
+// LineKind 表示hosts文件中一行的类型
+type LineKind int
+
+const (
+	// LineBlank 空行（仅包含空白字符）
+	LineBlank LineKind = iota
+	// LineComment 注释行
+	LineComment
+	// LineEntry Host条目行（包括被注释掉的条目）
+	LineEntry
+	// LineInvalid 无法识别为Host条目的非注释行
+	LineInvalid
+)
+
+// Entry 表示一条解析后的Host条目
+type Entry struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+	Comment   string   `json:"comment,omitempty"` // 行内注释（不含#）
+	Disabled  bool     `json:"disabled"`          // 是否被注释掉
+	Line      int      `json:"line"`              // 源文件中的行号（从1开始）
+}
+
+// Line 表示hosts文件中的一行
+type Line struct {
+	Number int      `json:"number"` // 行号（从1开始）
+	Raw    string   `json:"raw"`    // 原始文本（不含换行符）
+	Kind   LineKind `json:"kind"`
+	Entry  *Entry   `json:"entry,omitempty"` // 仅当Kind为LineEntry时有效
+}
+
+// File 表示解析后的hosts文件
+type File struct {
+	Lines []Line `json:"lines"`
+}
+
+// Parse 将hosts文本解析为结构化的行和条目
+func Parse(content string) *File {
+	rawLines := strings.Split(content, "\n")
+	file := &File{Lines: make([]Line, 0, len(rawLines))}
+
+	for i, raw := range rawLines {
+		file.Lines = append(file.Lines, ParseLine(raw, i+1))
+	}
+
+	return file
+}
+
+// ParseLine 解析单行hosts文本
+func ParseLine(raw string, number int) Line {
+	line := Line{Number: number, Raw: raw}
+
+	text := strings.TrimSpace(raw)
+	switch {
+	case text == "":
+		line.Kind = LineBlank
+	case strings.HasPrefix(text, "#"):
+		// 被注释掉的条目仍然是条目，只是处于禁用状态
+		if entry, ok := parseEntry(strings.TrimLeft(text, "#"), number); ok {
+			entry.Disabled = true
+			line.Kind = LineEntry
+			line.Entry = entry
+		} else {
+			line.Kind = LineComment
+		}
+	default:
+		if entry, ok := parseEntry(text, number); ok {
+			line.Kind = LineEntry
+			line.Entry = entry
+		} else {
+			line.Kind = LineInvalid
+		}
+	}
+
+	return line
+}
+
+// parseEntry 将去除注释前缀后的文本解析为条目
+func parseEntry(text string, number int) (*Entry, bool) {
+	comment := ""
+	if idx := strings.Index(text, "#"); idx >= 0 {
+		comment = strings.TrimSpace(text[idx+1:])
+		text = text[:idx]
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) < 2 || !IsIP(fields[0]) {
+		return nil, false
+	}
+
+	return &Entry{
+		IP:        fields[0],
+		Hostnames: fields[1:],
+		Comment:   comment,
+		Line:      number,
+	}, true
+}
+
+// IsIP 判断字符串是否为合法的IPv4/IPv6地址（允许IPv6区域标识，如fe80::1%lo0）
+func IsIP(s string) bool {
+	if idx := strings.Index(s, "%"); idx > 0 && strings.Contains(s, ":") {
+		s = s[:idx]
+	}
+	return net.ParseIP(s) != nil
+}
+
+// String 将解析结果还原为文本，与原始输入逐字节一致
+func (f *File) String() string {
+	raws := make([]string, len(f.Lines))
+	for i, line := range f.Lines {
+		raws[i] = line.Raw
+	}
+	return strings.Join(raws, "\n")
+}
+
+// Entries 返回所有条目（包括被注释掉的条目）
+func (f *File) Entries() []Entry {
+	var entries []Entry
+	for _, line := range f.Lines {
+		if line.Kind == LineEntry {
+			entries = append(entries, *line.Entry)
+		}
+	}
+	return entries
+}
+
+// ActiveEntries 返回所有生效的条目（不包括被注释掉的条目）
+func (f *File) ActiveEntries() []Entry {
+	var entries []Entry
+	for _, line := range f.Lines {
+		if line.Kind == LineEntry && !line.Entry.Disabled {
+			entries = append(entries, *line.Entry)
+		}
+	}
+	return entries
+}
+
+// String 将条目格式化为一行hosts文本
+func (e Entry) String() string {
+	var sb strings.Builder
+	if e.Disabled {
+		sb.WriteString("# ")
+	}
+	sb.WriteString(e.IP)
+	for _, hostname := range e.Hostnames {
+		sb.WriteString(" ")
+		sb.WriteString(hostname)
+	}
+	if e.Comment != "" {
+		sb.WriteString(" # ")
+		sb.WriteString(e.Comment)
+	}
+	return sb.String()
+}
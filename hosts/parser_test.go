@@ -0,0 +1,60 @@
+package hosts
+
+import "testing"
+
+// TestParseRoundTrip 测试解析后还原的文本与原始输入逐字节一致
+func TestParseRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"\n",
+		"127.0.0.1 localhost\n",
+		"127.0.0.1\tlocalhost loopback  # 本机\r\n::1 localhost\r\n",
+		"# comment only\n\n  \n#10.0.0.1 disabled.local\nnot a hosts line",
+		"fe80::1%lo0 localhost\n# >>> Ghost Host Entries\n",
+	}
+
+	for _, input := range inputs {
+		output := Parse(input).String()
+		if output != input {
+			t.Errorf("round trip mismatch: got %q, want %q", output, input)
+		}
+	}
+}
+
+// TestParseEntries 测试条目字段解析
+func TestParseEntries(t *testing.T) {
+	content := "# header\n127.0.0.1 a.local b.local # inline\n#  10.0.0.1 disabled.local\ngarbage\n"
+	file := Parse(content)
+
+	kinds := []LineKind{LineComment, LineEntry, LineEntry, LineInvalid, LineBlank}
+	if len(file.Lines) != len(kinds) {
+		t.Fatalf("expected %d lines, got %d", len(kinds), len(file.Lines))
+	}
+	for i, kind := range kinds {
+		if file.Lines[i].Kind != kind {
+			t.Errorf("line %d: expected kind %d, got %d", i+1, kind, file.Lines[i].Kind)
+		}
+	}
+
+	entries := file.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.IP != "127.0.0.1" || len(first.Hostnames) != 2 || first.Hostnames[1] != "b.local" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if first.Comment != "inline" || first.Disabled || first.Line != 2 {
+		t.Errorf("unexpected first entry metadata: %+v", first)
+	}
+
+	second := entries[1]
+	if !second.Disabled || second.IP != "10.0.0.1" || second.Line != 3 {
+		t.Errorf("unexpected second entry: %+v", second)
+	}
+
+	if active := file.ActiveEntries(); len(active) != 1 {
+		t.Errorf("expected 1 active entry, got %d", len(active))
+	}
+}
@@ -0,0 +1,204 @@
+package hosts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// PolicyReject 存在非法行时拒绝整个内容
+	PolicyReject = "reject"
+	// PolicyStrip 删除非法行，保留其余内容
+	PolicyStrip = "strip"
+	// PolicyWarn 保留全部内容，仅记录警告
+	PolicyWarn = "warn"
+)
+
+const (
+	// maxHostnameLength 主机名最大长度
+	maxHostnameLength = 253
+	// maxLabelLength 主机名中单个标签的最大长度
+	maxLabelLength = 63
+)
+
+// ErrNotHostsContent 内容整体上不是hosts格式（例如HTML错误页或登录页）
+var ErrNotHostsContent = errors.New("content does not look like a hosts file")
+
+// Issue 表示hosts内容中某一行的问题
+type Issue struct {
+	Line   int    `json:"line"`             // 行号（从1开始）
+	Text   string `json:"text"`             // 原始行内容
+	Reason string `json:"reason"`           // 问题描述
+	Source string `json:"source,omitempty"` // 问题所在的来源URL（仅多来源合并时设置）
+}
+
+// String 返回问题的可读描述
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d: %s: %q", i.Line, i.Reason, i.Text)
+}
+
+// IssueList 一组行级问题，可作为error返回
+type IssueList []Issue
+
+// Error 实现error接口
+func (l IssueList) Error() string {
+	if len(l) == 0 {
+		return "no issues"
+	}
+
+	const maxShown = 5
+	var parts []string
+	for i, issue := range l {
+		if i >= maxShown {
+			parts = append(parts, fmt.Sprintf("... and %d more", len(l)-maxShown))
+			break
+		}
+		parts = append(parts, issue.String())
+	}
+	return fmt.Sprintf("invalid hosts content (%d issues): %s", len(l), strings.Join(parts, "; "))
+}
+
+// Validate 逐行校验hosts内容，返回所有问题
+func Validate(content string) []Issue {
+	var issues []Issue
+	for _, line := range Parse(content).Lines {
+		if reason := lineProblem(line); reason != "" {
+			issues = append(issues, Issue{Line: line.Number, Text: line.Raw, Reason: reason})
+		}
+	}
+	return issues
+}
+
+// Sanitize 按照指定策略校验内容，返回处理后的内容和发现的问题
+// 当策略为reject且存在问题时返回IssueList错误；内容整体不是hosts格式时任何策略都返回ErrNotHostsContent
+func Sanitize(content, policy string) (string, []Issue, error) {
+	if LooksLikeHTML(content) {
+		return "", nil, ErrNotHostsContent
+	}
+
+	issues := Validate(content)
+	if len(issues) == 0 {
+		return content, nil, nil
+	}
+
+	switch policy {
+	case PolicyReject:
+		return "", issues, IssueList(issues)
+	case PolicyStrip:
+		bad := make(map[int]bool, len(issues))
+		for _, issue := range issues {
+			bad[issue.Line] = true
+		}
+
+		file := Parse(content)
+		kept := make([]Line, 0, len(file.Lines))
+		for _, line := range file.Lines {
+			if !bad[line.Number] {
+				kept = append(kept, line)
+			}
+		}
+		file.Lines = kept
+		return file.String(), issues, nil
+	default:
+		return content, issues, nil
+	}
+}
+
+// LooksLikeHTML 判断内容是否为HTML文档
+func LooksLikeHTML(content string) bool {
+	text := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(content, "\ufeff")))
+	return strings.HasPrefix(text, "<!doctype html") ||
+		strings.HasPrefix(text, "<html") ||
+		strings.HasPrefix(text, "<?xml") ||
+		strings.HasPrefix(text, "<head") ||
+		strings.HasPrefix(text, "<body")
+}
+
+// lineProblem 返回一行的问题描述，没有问题时返回空字符串
+func lineProblem(line Line) string {
+	switch line.Kind {
+	case LineInvalid:
+		return invalidLineReason(line.Raw)
+	case LineEntry:
+		if line.Entry.Disabled {
+			return ""
+		}
+		for _, hostname := range line.Entry.Hostnames {
+			if reason := ValidateHostname(hostname); reason != "" {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// invalidLineReason 为无法解析为条目的行给出具体原因
+func invalidLineReason(raw string) string {
+	text := strings.TrimSpace(raw)
+	if idx := strings.Index(text, "#"); idx >= 0 {
+		text = strings.TrimSpace(text[:idx])
+	}
+
+	if strings.HasPrefix(text, "<") {
+		return "non-hosts content (markup)"
+	}
+
+	fields := strings.Fields(text)
+	first := fields[0]
+	switch {
+	case IsIP(first):
+		return "missing hostname"
+	case strings.Contains(first, ":"):
+		return "invalid IPv6 address"
+	case looksLikeIPv4(first):
+		return "invalid IPv4 address"
+	default:
+		return "not a hosts entry"
+	}
+}
+
+// looksLikeIPv4 判断字符串是否由数字和点组成（即意图写成IPv4地址）
+func looksLikeIPv4(s string) bool {
+	if !strings.Contains(s, ".") {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateHostname 校验主机名，合法时返回空字符串，否则返回原因
+// 为兼容常见的屏蔽列表，标签中允许出现下划线
+func ValidateHostname(hostname string) string {
+	name := strings.TrimSuffix(hostname, ".")
+	if name == "" {
+		return "empty hostname"
+	}
+	if len(name) > maxHostnameLength {
+		return fmt.Sprintf("hostname exceeds %d characters", maxHostnameLength)
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return "hostname contains an empty label"
+		}
+		if len(label) > maxLabelLength {
+			return fmt.Sprintf("hostname label exceeds %d characters", maxLabelLength)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return "hostname label starts or ends with a hyphen"
+		}
+		for _, r := range label {
+			isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+			if !isAlnum && r != '-' && r != '_' {
+				return fmt.Sprintf("hostname contains illegal character %q", r)
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,88 @@
+package hosts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestValidate 测试逐行校验给出的问题和原因
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		line   string
+		reason string // 为空表示没有问题
+	}{
+		{"127.0.0.1 localhost", ""},
+		{"::1 localhost ip6-localhost", ""},
+		{"0.0.0.0 ads_tracker.example.com # underscore", ""},
+		{"# 999.1.1.1 disabled.example.com", ""},
+		{"", ""},
+		{"127.0.0.1", "missing hostname"},
+		{"999.1.1.1 bad.example.com", "invalid IPv4 address"},
+		{"fe80:::1 bad.example.com", "invalid IPv6 address"},
+		{"<html><body>", "non-hosts content (markup)"},
+		{"example.com", "not a hosts entry"},
+		{"127.0.0.1 -bad.example.com", "hostname label starts or ends with a hyphen"},
+		{"127.0.0.1 bad..example.com", "hostname contains an empty label"},
+		{"127.0.0.1 bad!.example.com", "hostname contains illegal character '!'"},
+		{"127.0.0.1 " + strings.Repeat("a", 64) + ".com", "hostname label exceeds 63 characters"},
+		{"127.0.0.1 " + strings.Repeat("a.", 127) + "com", "hostname exceeds 253 characters"},
+	}
+
+	for _, tt := range tests {
+		issues := Validate(tt.line)
+		switch {
+		case tt.reason == "" && len(issues) != 0:
+			t.Errorf("%q: expected no issues, got %+v", tt.line, issues)
+		case tt.reason != "" && len(issues) != 1:
+			t.Errorf("%q: expected 1 issue, got %+v", tt.line, issues)
+		case tt.reason != "" && issues[0].Reason != tt.reason:
+			t.Errorf("%q: expected reason %q, got %q", tt.line, tt.reason, issues[0].Reason)
+		}
+	}
+}
+
+// TestSanitize 测试三种策略对非法行的处理
+func TestSanitize(t *testing.T) {
+	content := "127.0.0.1 good.local\n999.0.0.1 bad.local\n# comment\n10.0.0.1 ok.local\n"
+
+	tests := []struct {
+		name    string
+		content string
+		policy  string
+		want    string
+		issues  int
+		err     error
+	}{
+		{"clean content", "127.0.0.1 good.local\n", PolicyReject, "127.0.0.1 good.local\n", 0, nil},
+		{"reject", content, PolicyReject, "", 1, IssueList{}},
+		{"strip", content, PolicyStrip, "127.0.0.1 good.local\n# comment\n10.0.0.1 ok.local\n", 1, nil},
+		{"warn", content, PolicyWarn, content, 1, nil},
+		{"html page", "<!DOCTYPE html>\n<html></html>", PolicyWarn, "", 0, ErrNotHostsContent},
+	}
+
+	for _, tt := range tests {
+		got, issues, err := Sanitize(tt.content, tt.policy)
+		switch tt.err.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+		case IssueList:
+			var list IssueList
+			if !errors.As(err, &list) {
+				t.Errorf("%s: expected IssueList error, got %v", tt.name, err)
+			}
+		default:
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: expected error %v, got %v", tt.name, tt.err, err)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected content %q, got %q", tt.name, tt.want, got)
+		}
+		if len(issues) != tt.issues {
+			t.Errorf("%s: expected %d issues, got %d", tt.name, tt.issues, len(issues))
+		}
+	}
+}
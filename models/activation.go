@@ -0,0 +1,255 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Windows等系统可能没有时区数据库
+)
+
+const (
+	// ActivationEnable 触发时启用分组
+	ActivationEnable = "enable"
+	// ActivationDisable 触发时禁用分组
+	ActivationDisable = "disable"
+)
+
+// activationHorizon 查找切换时间点的范围，覆盖4年以包含只在2月29日触发的规则
+const activationHorizon = 4 * 366 * 24 * time.Hour
+
+// ActivationSchedule 分组的自动启用计划，由cron规则和按星期的时间段组成
+// 每个规则或时间段的边界都是一个切换时间点，分组状态由最近一次切换决定
+type ActivationSchedule struct {
+	Timezone string           `json:"timezone,omitempty"` // IANA时区名，为空时使用本地时区
+	Rules    []ActivationRule `json:"rules,omitempty"`
+	Windows  []TimeWindow     `json:"windows,omitempty"`
+}
+
+// ActivationRule 在cron表达式触发时启用或禁用分组
+type ActivationRule struct {
+	Cron   string `json:"cron"`   // 5字段cron表达式或@daily等简写
+	Action string `json:"action"` // enable、disable
+}
+
+// TimeWindow 按星期重复的时间段，开始时启用分组，结束时禁用分组
+type TimeWindow struct {
+	Days  []int  `json:"days,omitempty"` // 开始时间所在的星期（0为周日），为空表示每天
+	Start string `json:"start"`          // 开始时间（HH:MM）
+	End   string `json:"end"`            // 结束时间（HH:MM），早于开始时间时表示跨越午夜
+}
+
+// ActivationEvent 一次计划中的切换
+type ActivationEvent struct {
+	At      time.Time
+	Enabled bool
+	Reason  string
+}
+
+// activationPlan 解析后的计划
+type activationPlan struct {
+	location *time.Location
+	rules    []*cronSchedule
+	actions  []string
+	exprs    []string
+	windows  []timeWindowPlan
+}
+
+// timeWindowPlan 解析后的时间段，时间以当天的分钟数表示
+type timeWindowPlan struct {
+	days       uint8
+	start, end int
+	label      string
+}
+
+// Validate 校验计划的时区、规则和时间段
+func (s *ActivationSchedule) Validate() error {
+	_, err := s.compile()
+	return err
+}
+
+// compile 解析计划
+func (s *ActivationSchedule) compile() (*activationPlan, error) {
+	if len(s.Rules) == 0 && len(s.Windows) == 0 {
+		return nil, fmt.Errorf("activation schedule must contain at least one rule or time window")
+	}
+
+	plan := &activationPlan{location: time.Local}
+	if s.Timezone != "" {
+		location, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+		}
+		plan.location = location
+	}
+
+	for _, rule := range s.Rules {
+		if rule.Action != ActivationEnable && rule.Action != ActivationDisable {
+			return nil, fmt.Errorf("invalid activation action %q", rule.Action)
+		}
+		cron, err := parseCron(rule.Cron)
+		if err != nil {
+			return nil, err
+		}
+		plan.rules = append(plan.rules, cron)
+		plan.actions = append(plan.actions, rule.Action)
+		plan.exprs = append(plan.exprs, strings.TrimSpace(rule.Cron))
+	}
+
+	for _, window := range s.Windows {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, fmt.Errorf("time window %s-%s is empty", window.Start, window.End)
+		}
+
+		wp := timeWindowPlan{start: start, end: end, label: window.Start + "-" + window.End}
+		for _, day := range window.Days {
+			if day < 0 || day > 6 {
+				return nil, fmt.Errorf("invalid weekday %d in time window %s", day, wp.label)
+			}
+			wp.days |= 1 << uint(day)
+		}
+		if wp.days == 0 {
+			wp.days = 0x7f
+		}
+		plan.windows = append(plan.windows, wp)
+	}
+
+	return plan, nil
+}
+
+// parseClock 将HH:MM解析为当天的分钟数
+func parseClock(text string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: expected HH:MM", text)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// eventAt 返回在t所在分钟发生的切换，同一分钟内启用优先于禁用
+func (p *activationPlan) eventAt(t time.Time) (ActivationEvent, bool) {
+	local := t.In(p.location)
+	minute := local.Hour()*60 + local.Minute()
+	weekday := uint(local.Weekday())
+	yesterday := (weekday + 6) % 7
+
+	var disable *ActivationEvent
+	for i, rule := range p.rules {
+		if !rule.matches(local) {
+			continue
+		}
+		reason := fmt.Sprintf("cron rule %q (%s)", p.exprs[i], p.actions[i])
+		if p.actions[i] == ActivationEnable {
+			return ActivationEvent{At: t, Enabled: true, Reason: reason}, true
+		}
+		if disable == nil {
+			disable = &ActivationEvent{At: t, Reason: reason}
+		}
+	}
+
+	for _, w := range p.windows {
+		if minute == w.start && w.days&(1<<weekday) != 0 {
+			return ActivationEvent{At: t, Enabled: true, Reason: fmt.Sprintf("time window %s started", w.label)}, true
+		}
+		// 跨越午夜的时间段在开始的第二天结束
+		startDay := weekday
+		if w.end < w.start {
+			startDay = yesterday
+		}
+		if minute == w.end && w.days&(1<<startDay) != 0 && disable == nil {
+			disable = &ActivationEvent{At: t, Reason: fmt.Sprintf("time window %s ended", w.label)}
+		}
+	}
+
+	if disable != nil {
+		return *disable, true
+	}
+	return ActivationEvent{}, false
+}
+
+// mayFireOn 判断t所在的日期是否可能有切换，用于跳过整天
+func (p *activationPlan) mayFireOn(local time.Time) bool {
+	for _, rule := range p.rules {
+		if rule.matchesDay(local) {
+			return true
+		}
+	}
+
+	weekday := uint(local.Weekday())
+	yesterday := (weekday + 6) % 7
+	for _, w := range p.windows {
+		// 跨越午夜的时间段可能在开始的第二天结束
+		if w.days&(1<<weekday) != 0 || (w.end < w.start && w.days&(1<<yesterday) != 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// search 从from开始按step（正负一分钟）逐分钟查找切换，没有切换的日期整天跳过
+// 按绝对时间逐分钟前进，夏令时跳过的本地时间不会触发，重复的本地时间会触发两次
+func (p *activationPlan) search(from time.Time, step time.Duration) (ActivationEvent, bool) {
+	for t := from; t.Sub(from).Abs() < activationHorizon; {
+		local := t.In(p.location)
+		if !p.mayFireOn(local) {
+			t = skipDay(t, local, step)
+			continue
+		}
+		if event, ok := p.eventAt(t); ok {
+			return event, true
+		}
+		t = t.Add(step)
+	}
+	return ActivationEvent{}, false
+}
+
+// skipDay 返回step方向上相邻一天中离t最近的分钟
+func skipDay(t, local time.Time, step time.Duration) time.Time {
+	year, month, day := local.Date()
+	var next time.Time
+	if step > 0 {
+		next = time.Date(year, month, day+1, 0, 0, 0, 0, local.Location())
+	} else {
+		next = time.Date(year, month, day, 0, 0, 0, 0, local.Location()).Add(-time.Minute)
+	}
+	// 午夜恰好处于夏令时切换时可能无法前进，此时退回逐分钟查找
+	if (step > 0 && !next.After(t)) || (step < 0 && !next.Before(t)) {
+		return t.Add(step)
+	}
+	return next
+}
+
+// LastEvent 返回不晚于now的最近一次切换，查找范围内没有切换时返回nil
+func (s *ActivationSchedule) LastEvent(now time.Time) (*ActivationEvent, error) {
+	plan, err := s.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	event, ok := plan.search(now.Truncate(time.Minute), -time.Minute)
+	if !ok {
+		return nil, nil
+	}
+	return &event, nil
+}
+
+// NextEvent 返回now之后的下一次切换时间，查找范围内没有切换时返回查找范围的终点
+func (s *ActivationSchedule) NextEvent(now time.Time) (time.Time, error) {
+	plan, err := s.compile()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	event, ok := plan.search(now.Truncate(time.Minute).Add(time.Minute), time.Minute)
+	if !ok {
+		return now.Add(activationHorizon), nil
+	}
+	return event.At, nil
+}
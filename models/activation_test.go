@@ -0,0 +1,241 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// mustTime 解析指定时区的本地时间
+func mustTime(t *testing.T, zone, value string) time.Time {
+	t.Helper()
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := time.ParseInLocation("2006-01-02 15:04", value, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// TestParseCron 测试cron表达式的解析和校验
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr  string
+		valid bool
+	}{
+		{"*/15 * * * *", true},
+		{"0 9-17 * * mon-fri", true},
+		{"5 4 1,15 jan,jul *", true},
+		{"0 0 * * 7", true},
+		{"@daily", true},
+		{"@YEARLY", true},
+		{"* * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * * 8", false},
+		{"*/0 * * * *", false},
+		{"5-1 * * * *", false},
+		{"@every 5m", false},
+	}
+
+	for _, tt := range tests {
+		_, err := parseCron(tt.expr)
+		if (err == nil) != tt.valid {
+			t.Errorf("parseCron(%q): expected valid=%t, got error %v", tt.expr, tt.valid, err)
+		}
+	}
+}
+
+// TestCronMatches 测试cron表达式在指定时间是否触发
+func TestCronMatches(t *testing.T) {
+	tests := []struct {
+		expr string
+		at   string
+		want bool
+	}{
+		{"*/15 * * * *", "2026-10-18 10:45", true},
+		{"*/15 * * * *", "2026-10-18 10:46", false},
+		{"0 9-17 * * mon-fri", "2026-10-19 09:00", true},  // 周一
+		{"0 9-17 * * mon-fri", "2026-10-18 09:00", false}, // 周日
+		{"0 0 * * 7", "2026-10-18 00:00", true},           // 7表示周日
+		{"0 0 1 * mon", "2026-10-01 00:00", true},         // 日和周同时限定时满足任意一个即可
+		{"0 0 1 * mon", "2026-10-19 00:00", true},
+		{"0 0 1 * mon", "2026-10-20 00:00", false},
+		{"0 0 1-7 * *", "2026-10-08 00:00", false},
+		{"@monthly", "2026-11-01 00:00", true},
+	}
+
+	for _, tt := range tests {
+		cron, err := parseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tt.expr, err)
+		}
+		if got := cron.matches(mustTime(t, "UTC", tt.at)); got != tt.want {
+			t.Errorf("%q at %s: expected %t, got %t", tt.expr, tt.at, tt.want, got)
+		}
+	}
+}
+
+// TestActivationEvents 测试最近一次和下一次切换的计算，包括间隔很长的规则和时间段
+func TestActivationEvents(t *testing.T) {
+	weekendNight := []TimeWindow{{Days: []int{5}, Start: "22:00", End: "06:00"}}
+
+	tests := []struct {
+		name     string
+		schedule ActivationSchedule
+		now      string
+		last     string // 为空表示没有切换
+		enabled  bool
+		next     string
+	}{
+		{
+			name:     "daily rules",
+			schedule: ActivationSchedule{Rules: []ActivationRule{{"0 9 * * *", ActivationEnable}, {"0 18 * * *", ActivationDisable}}},
+			now:      "2026-10-18 12:30",
+			last:     "2026-10-18 09:00", enabled: true,
+			next: "2026-10-18 18:00",
+		},
+		{
+			name:     "monthly",
+			schedule: ActivationSchedule{Rules: []ActivationRule{{"@monthly", ActivationEnable}}},
+			now:      "2026-10-18 12:30",
+			last:     "2026-10-01 00:00", enabled: true,
+			next: "2026-11-01 00:00",
+		},
+		{
+			name:     "yearly",
+			schedule: ActivationSchedule{Rules: []ActivationRule{{"@yearly", ActivationDisable}}},
+			now:      "2026-10-18 12:30",
+			last:     "2026-01-01 00:00", enabled: false,
+			next: "2027-01-01 00:00",
+		},
+		{
+			name:     "leap day",
+			schedule: ActivationSchedule{Rules: []ActivationRule{{"0 0 29 2 *", ActivationEnable}}},
+			now:      "2026-10-18 12:30",
+			last:     "2024-02-29 00:00", enabled: true,
+			next: "2028-02-29 00:00",
+		},
+		{
+			name:     "enable wins in the same minute",
+			schedule: ActivationSchedule{Rules: []ActivationRule{{"0 8 * * *", ActivationDisable}, {"0 8 * * *", ActivationEnable}}},
+			now:      "2026-10-18 08:00",
+			last:     "2026-10-18 08:00", enabled: true,
+			next: "2026-10-19 08:00",
+		},
+		{
+			name:     "window across midnight, inside",
+			schedule: ActivationSchedule{Windows: weekendNight},
+			now:      "2026-10-24 03:00", // 周六
+			last:     "2026-10-23 22:00", enabled: true,
+			next: "2026-10-24 06:00",
+		},
+		{
+			name:     "window across midnight, after",
+			schedule: ActivationSchedule{Windows: weekendNight},
+			now:      "2026-10-26 12:00", // 周一
+			last:     "2026-10-24 06:00", enabled: false,
+			next: "2026-10-30 22:00",
+		},
+		{
+			name:     "never fires",
+			schedule: ActivationSchedule{Rules: []ActivationRule{{"0 0 31 2 *", ActivationEnable}}},
+			now:      "2026-10-18 12:30",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.schedule.Timezone = "UTC"
+		now := mustTime(t, "UTC", tt.now)
+
+		event, err := tt.schedule.LastEvent(now)
+		if err != nil {
+			t.Fatalf("%s: LastEvent: %v", tt.name, err)
+		}
+		switch {
+		case tt.last == "" && event != nil:
+			t.Errorf("%s: expected no event, got %+v", tt.name, event)
+		case tt.last != "" && event == nil:
+			t.Errorf("%s: expected event at %s, got none", tt.name, tt.last)
+		case tt.last != "" && (!event.At.Equal(mustTime(t, "UTC", tt.last)) || event.Enabled != tt.enabled):
+			t.Errorf("%s: expected event at %s (enabled=%t), got %s (enabled=%t)", tt.name, tt.last, tt.enabled, event.At, event.Enabled)
+		}
+
+		next, err := tt.schedule.NextEvent(now)
+		if err != nil {
+			t.Fatalf("%s: NextEvent: %v", tt.name, err)
+		}
+		want := now.Add(activationHorizon)
+		if tt.next != "" {
+			want = mustTime(t, "UTC", tt.next)
+		}
+		if !next.Equal(want) {
+			t.Errorf("%s: expected next event at %s, got %s", tt.name, want, next)
+		}
+	}
+}
+
+// TestActivationDST 测试夏令时切换：跳过的本地时间不触发，重复的本地时间触发两次
+func TestActivationDST(t *testing.T) {
+	const zone = "America/New_York"
+
+	tests := []struct {
+		name string
+		cron string
+		now  string // 本地时间
+		next time.Time
+	}{
+		{
+			// 2026-03-08 02:00 跳到 03:00，当天没有02:30
+			name: "spring forward",
+			cron: "30 2 * * *",
+			now:  "2026-03-07 03:00",
+			next: mustTime(t, zone, "2026-03-09 02:30"),
+		},
+		{
+			// 2026-11-01 02:00 回到 01:00，01:30出现两次
+			name: "fall back",
+			cron: "30 1 * * *",
+			now:  "2026-11-01 01:45",
+			next: time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		schedule := ActivationSchedule{Timezone: zone, Rules: []ActivationRule{{tt.cron, ActivationEnable}}}
+		next, err := schedule.NextEvent(mustTime(t, zone, tt.now))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !next.Equal(tt.next) {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.next.UTC(), next.UTC())
+		}
+	}
+}
+
+// TestActivationValidate 测试计划的校验
+func TestActivationValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule ActivationSchedule
+		valid    bool
+	}{
+		{"empty", ActivationSchedule{}, false},
+		{"bad timezone", ActivationSchedule{Timezone: "Mars/Base", Rules: []ActivationRule{{"@daily", ActivationEnable}}}, false},
+		{"bad action", ActivationSchedule{Rules: []ActivationRule{{"@daily", "toggle"}}}, false},
+		{"bad clock", ActivationSchedule{Windows: []TimeWindow{{Start: "25:00", End: "06:00"}}}, false},
+		{"empty window", ActivationSchedule{Windows: []TimeWindow{{Start: "09:00", End: "09:00"}}}, false},
+		{"bad weekday", ActivationSchedule{Windows: []TimeWindow{{Days: []int{7}, Start: "09:00", End: "17:00"}}}, false},
+		{"valid", ActivationSchedule{Timezone: "Asia/Shanghai", Windows: []TimeWindow{{Days: []int{1, 5}, Start: "09:00", End: "17:00"}}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.schedule.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%t, got error %v", tt.name, tt.valid, err)
+		}
+	}
+}
@@ -1,5 +1,12 @@
 package models
 
+import (
+	"reflect"
+	"time"
+
+	"ghost/hosts"
+)
+
 // HostGroup 表示一个Host分组
 type HostGroup struct {
 	ID              string `json:"id"`
@@ -13,6 +20,205 @@ type HostGroup struct {
 	LastUpdated     string `json:"lastUpdated"`     // 最后更新时间
 	CreatedAt       string `json:"createdAt"`
 	UpdatedAt       string `json:"updatedAt"`
+
+	Priority         int           `json:"priority"`                   // 优先级，数值越大越靠前，去重合并时优先保留
+	ValidationPolicy string        `json:"validationPolicy,omitempty"` // 内容校验策略：reject、strip、warn
+	Issues           []hosts.Issue `json:"issues,omitempty"`           // 最近一次校验发现的问题
+
+	ETag         string `json:"etag,omitempty"`         // 远程响应的ETag，用于条件请求
+	LastModified string `json:"lastModified,omitempty"` // 远程响应的Last-Modified，用于条件请求
+	LastChecked  string `json:"lastChecked,omitempty"`  // 最后一次检查远程内容的时间（包括未变化的情况）
+
+	Health RemoteHealth `json:"health"` // 远程刷新的健康状态
+
+	Proxy        string `json:"proxy,omitempty"`        // 覆盖全局代理设置，"direct"表示不使用代理
+	CABundle     string `json:"caBundle,omitempty"`     // 覆盖全局CA证书文件
+	FetchTimeout int64  `json:"fetchTimeout,omitempty"` // 覆盖全局请求超时时间（秒）
+
+	SHA256    string `json:"sha256,omitempty"`    // 远程内容的SHA-256固定值（十六进制）
+	PublicKey string `json:"publicKey,omitempty"` // 校验分离签名（URL.sig）的ed25519或minisign公钥
+
+	SourceFormat string `json:"sourceFormat,omitempty"` // 远程内容格式：hosts、domains、adblock、dnsmasq、rpz
+	SinkAddress  string `json:"sinkAddress,omitempty"`  // 转换屏蔽列表时使用的地址，默认0.0.0.0
+
+	Sources       []RemoteSource `json:"sources,omitempty"`       // 有序的来源列表，为空时只使用URL
+	SourceMode    string         `json:"sourceMode,omitempty"`    // 多来源的使用方式：fallback、merge
+	ContentSource string         `json:"contentSource,omitempty"` // 当前内容来自的来源URL（fallback模式）
+
+	IsFile      bool   `json:"isFile"`                // 是否为本地文件来源
+	FilePath    string `json:"filePath,omitempty"`    // 本地文件路径或file:// URL（仅当IsFile=true时有效）
+	FileModTime string `json:"fileModTime,omitempty"` // 最后一次读取时文件的修改时间
+	FileSize    int64  `json:"fileSize,omitempty"`    // 最后一次读取时文件的大小
+	AutoApply   string `json:"autoApply,omitempty"`   // 内容变化后是否自动应用：on、off，为空时使用全局设置
+
+	Activation   *ActivationSchedule `json:"activation,omitempty"`   // 自动启用和禁用的计划
+	ActivationAt string              `json:"activationAt,omitempty"` // 最后一次已处理的计划切换时间点
+
+	ExpiresAt        string `json:"expiresAt,omitempty"`        // 临时启用的到期时间，到期后自动禁用
+	RemainingSeconds int64  `json:"remainingSeconds,omitempty"` // 距到期的剩余秒数，仅在获取分组时计算，不保存
+}
+
+// UpdateRemaining 根据到期时间计算剩余秒数，已到期时为0
+func (g *HostGroup) UpdateRemaining(now time.Time) {
+	g.RemainingSeconds = 0
+	expiresAt, err := time.Parse(time.RFC3339, g.ExpiresAt)
+	if err != nil || !g.Enabled {
+		return
+	}
+	if remaining := expiresAt.Sub(now); remaining > 0 {
+		g.RemainingSeconds = int64((remaining + time.Second - 1) / time.Second)
+	}
+}
+
+const (
+	// AutoApplyOn 内容变化后自动应用到系统hosts文件
+	AutoApplyOn = "on"
+	// AutoApplyOff 内容变化后不自动应用
+	AutoApplyOff = "off"
+)
+
+// ShouldAutoApply 判断分组内容变化后是否需要自动应用，只有启用的分组才会触发
+func (g *HostGroup) ShouldAutoApply(config *AppConfig) bool {
+	if !g.Enabled {
+		return false
+	}
+	switch g.AutoApply {
+	case AutoApplyOn:
+		return true
+	case AutoApplyOff:
+		return false
+	}
+	return config != nil && config.AutoApply
+}
+
+// AutoApplyStatus 自动应用的最近状态
+type AutoApplyStatus struct {
+	Pending     []string `json:"pending"`               // 等待应用的分组ID
+	ScheduledAt string   `json:"scheduledAt,omitempty"` // 计划执行的时间
+	LastAttempt string   `json:"lastAttempt,omitempty"` // 最后一次执行的时间
+	LastSuccess string   `json:"lastSuccess,omitempty"` // 最后一次成功的时间
+	LastError   string   `json:"lastError,omitempty"`   // 最后一次失败的错误信息
+	LastGroups  []string `json:"lastGroups,omitempty"`  // 最后一次执行时触发的分组ID
+}
+
+// ScheduleEntry 调度器中一个分组的刷新或启用计划
+type ScheduleEntry struct {
+	GroupID  string `json:"groupId"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`              // remote、file、activation或expiry
+	Interval int64  `json:"interval"`          // 刷新间隔（秒），启用计划和到期任务为0
+	NextRun  string `json:"nextRun,omitempty"` // 下一次执行时间，正在执行时为空
+	LastRun  string `json:"lastRun,omitempty"` // 本次运行期间最后一次执行的时间
+	Running  bool   `json:"running"`
+}
+
+// DefaultFileWatchInterval 本地文件来源未设置刷新间隔时的检查间隔（秒）
+const DefaultFileWatchInterval = 5
+
+// RemoteHealth 远程分组刷新的健康状态
+type RemoteHealth struct {
+	LastSuccess         string `json:"lastSuccess,omitempty"` // 最后一次成功刷新的时间
+	LastError           string `json:"lastError,omitempty"`   // 最后一次失败的错误信息
+	LastErrorAt         string `json:"lastErrorAt,omitempty"` // 最后一次失败的时间
+	ConsecutiveFailures int    `json:"consecutiveFailures"`   // 连续失败次数
+	NextAttempt         string `json:"nextAttempt,omitempty"` // 下一次计划刷新的时间，读取分组时由调度器填充，不保存
+}
+
+// RecordRefresh 根据一次刷新的结果更新健康状态
+func (g *HostGroup) RecordRefresh(err error, now time.Time) {
+	if err != nil {
+		g.Health.LastError = err.Error()
+		g.Health.LastErrorAt = now.Format(time.RFC3339)
+		g.Health.ConsecutiveFailures++
+	} else {
+		g.Health.LastSuccess = now.Format(time.RFC3339)
+		g.Health.LastError = ""
+		g.Health.ConsecutiveFailures = 0
+	}
+
+	// 下一次刷新的时间由调度器决定（包括抖动和全局刷新间隔），这里不再推算
+	g.Health.NextAttempt = ""
+}
+
+// KeepRuntimeState 从旧的分组中保留由后端维护的状态字段
+// 前端提交的分组不包含这些字段，更新分组时需要保留
+func (g *HostGroup) KeepRuntimeState(old *HostGroup) {
+	// 来源、完整性或格式设置变化后缓存验证信息不再有效，需要重新下载并处理
+	contentSource := old.CurrentSourceURL()
+	if g.SHA256 == old.SHA256 && g.PublicKey == old.PublicKey && g.SinkAddress == old.SinkAddress &&
+		g.EffectiveSourceMode() == old.EffectiveSourceMode() &&
+		g.SourceFormatFor(contentSource) == old.SourceFormatFor(contentSource) && g.HasSource(contentSource) {
+		g.ETag = old.ETag
+		g.LastModified = old.LastModified
+		g.ContentSource = old.ContentSource
+	}
+	g.keepSourceStatus(old)
+	if g.FilePath == old.FilePath {
+		g.FileModTime = old.FileModTime
+		g.FileSize = old.FileSize
+	}
+	// 计划变化后重新按新计划的最近一次切换决定状态
+	g.ActivationAt = ""
+	if reflect.DeepEqual(g.Activation, old.Activation) {
+		g.ActivationAt = old.ActivationAt
+	}
+	// 禁用分组时取消临时启用的到期时间
+	g.ExpiresAt = ""
+	if g.Enabled {
+		g.ExpiresAt = old.ExpiresAt
+	}
+	g.RemainingSeconds = 0
+	g.LastChecked = old.LastChecked
+	g.Health = old.Health
+}
+
+// EffectiveValidationPolicy 返回实际生效的校验策略
+// 未配置时远程组默认删除非法行，本地组默认仅警告
+func (g *HostGroup) EffectiveValidationPolicy() string {
+	switch g.ValidationPolicy {
+	case hosts.PolicyReject, hosts.PolicyStrip, hosts.PolicyWarn:
+		return g.ValidationPolicy
+	}
+	if g.IsRemote {
+		return hosts.PolicyStrip
+	}
+	return hosts.PolicyWarn
+}
+
+// SanitizeContent 按照组的校验策略处理内容
+// 成功时返回处理后的内容并记录发现的问题，失败时组保持不变
+func (g *HostGroup) SanitizeContent(content string) (string, error) {
+	sanitized, issues, err := hosts.Sanitize(content, g.EffectiveValidationPolicy())
+	if err != nil {
+		return "", err
+	}
+	g.Issues = issues
+	return sanitized, nil
+}
+
+// ConvertContent 按指定来源格式转换原始内容并按分组的校验策略处理，不修改分组
+func (g *HostGroup) ConvertContent(raw, format string) (string, []hosts.Issue, error) {
+	converted, conversionIssues, err := hosts.Convert(raw, format, g.SinkAddress)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content, issues, err := hosts.Sanitize(converted, g.EffectiveValidationPolicy())
+	if err != nil {
+		return "", nil, err
+	}
+
+	return content, hosts.MergeIssues(conversionIssues, issues), nil
+}
+
+// ParsedContent 将Content解析为结构化的hosts文件
+func (g *HostGroup) ParsedContent() *hosts.File {
+	return hosts.Parse(g.Content)
+}
+
+// Entries 返回Content中解析出的所有条目（包括被注释掉的条目）
+func (g *HostGroup) Entries() []hosts.Entry {
+	return g.ParsedContent().Entries()
 }
 
 // RemoteConfig 远程Host配置
@@ -25,16 +231,54 @@ type RemoteConfig struct {
 
 // AppConfig 应用程序配置
 type AppConfig struct {
-	AutoRefresh     bool     `json:"autoRefresh"`     // 是否自动刷新远程Host
-	RefreshInterval int64    `json:"refreshInterval"` // 刷新间隔（纳秒）
-	ActiveGroups    []string `json:"activeGroups"`    // 当前激活的分组ID列表
+	AutoRefresh     bool     `json:"autoRefresh"`     // 是否自动刷新未设置刷新间隔的远程分组
+	RefreshInterval int64    `json:"refreshInterval"` // 未设置刷新间隔的远程分组使用的刷新间隔（秒）
+	ActiveGroups    []string `json:"activeGroups"`    // 最近一次切换配置方案时启用的分组ID列表
 	BackupEnabled   bool     `json:"backupEnabled"`   // 是否启用备份
 	MaxBackups      int      `json:"maxBackups"`      // 最大备份数量
 	SystemHostPath  string   `json:"systemHostPath"`  // 系统Host文件路径
 	CreatedAt       string   `json:"createdAt"`
 	UpdatedAt       string   `json:"updatedAt"`
+
+	ConflictPolicy  string `json:"conflictPolicy,omitempty"` // 应用时遇到主机名冲突的处理策略：ignore、warn、refuse
+	MergeMode       string `json:"mergeMode,omitempty"`      // 分组合并方式：concat、dedupe
+	MaxApplyHistory int    `json:"maxApplyHistory"`          // 最多保留的应用历史数量
+	FetchRetries    int    `json:"fetchRetries"`             // 远程获取失败后的最大重试次数
+	RetryBaseDelay  int64  `json:"retryBaseDelay"`           // 首次重试前的等待时间（秒），之后按指数增长
+
+	Proxy        string `json:"proxy,omitempty"`    // 远程获取使用的代理（http、https、socks5），为空时使用环境变量
+	CABundle     string `json:"caBundle,omitempty"` // 额外信任的CA证书文件（PEM）
+	FetchTimeout int64  `json:"fetchTimeout"`       // 单次请求超时时间（秒）
+
+	MaxRemoteSize int64 `json:"maxRemoteSize"` // 远程内容解压后的最大大小（MB）
+
+	MaxChangeHistory int `json:"maxChangeHistory"` // 每个分组最多保留的内容变化记录数量
+
+	AutoApply      bool  `json:"autoApply"`      // 分组内容变化后是否自动应用到系统hosts文件（分组可单独覆盖）
+	AutoApplyDelay int64 `json:"autoApplyDelay"` // 自动应用前等待的时间（秒），期间的多次变化合并为一次写入
+
+	ActiveProfile string `json:"activeProfile,omitempty"` // 当前使用的配置方案名称
 }
 
+// ProxyDirect 表示不使用任何代理（包括环境变量中的代理）
+const ProxyDirect = "direct"
+
+const (
+	// MergeModeConcat 按优先级顺序直接拼接各分组内容（默认）
+	MergeModeConcat = "concat"
+	// MergeModeDedupe 按优先级去重，同一主机名只保留优先级最高的分组中的映射
+	MergeModeDedupe = "dedupe"
+)
+
+const (
+	// ConflictPolicyIgnore 忽略冲突
+	ConflictPolicyIgnore = "ignore"
+	// ConflictPolicyWarn 记录冲突但继续应用（默认）
+	ConflictPolicyWarn = "warn"
+	// ConflictPolicyRefuse 存在冲突时拒绝应用
+	ConflictPolicyRefuse = "refuse"
+)
+
 // HostManager 管理所有Host分组
 type HostManager struct {
 	Config    AppConfig   `json:"config"`
@@ -42,4 +286,178 @@ type HostManager struct {
 	Version   string      `json:"version"` // 配置版本
 	CreatedAt string      `json:"createdAt"`
 	UpdatedAt string      `json:"updatedAt"`
+
+	LastSectionHash string `json:"lastSectionHash,omitempty"` // 最近一次写入的Ghost段的SHA-256，用于检测手动修改
+
+	Profiles []Profile `json:"profiles,omitempty"` // 配置方案
+}
+
+// RecoveryEvent 记录一次损坏数据文件的自动恢复
+type RecoveryEvent struct {
+	File         string `json:"file"`                  // 损坏的文件名
+	Reason       string `json:"reason"`                // 损坏原因（解析错误）
+	CorruptCopy  string `json:"corruptCopy,omitempty"` // 损坏文件保留的副本名
+	RestoredFrom string `json:"restoredFrom"`          // 恢复来源（备份文件名或defaults）
+	Timestamp    string `json:"timestamp"`
+}
+
+// ApplyPreview 预览应用Host分组后系统hosts文件的变化
+type ApplyPreview struct {
+	Content   string             `json:"content"`   // 将要写入的完整内容
+	Diff      string             `json:"diff"`      // 与当前系统hosts文件的统一diff
+	Added     int                `json:"added"`     // 新增的主机名数量
+	Removed   int                `json:"removed"`   // 删除的主机名数量
+	Changed   int                `json:"changed"`   // 地址发生变化的主机名数量
+	Changes   hosts.EntryChanges `json:"changes"`   // 条目级别的详细变化
+	Conflicts []hosts.Conflict   `json:"conflicts"` // 应用时会检测到的主机名冲突
+}
+
+const (
+	// ApplyKindApply 由应用Host分组产生的记录
+	ApplyKindApply = "apply"
+	// ApplyKindRollback 由回滚产生的记录
+	ApplyKindRollback = "rollback"
+)
+
+// ApplyRecord 一次写入系统hosts文件的历史记录
+type ApplyRecord struct {
+	ID              string   `json:"id"`
+	Kind            string   `json:"kind"` // apply或rollback
+	Timestamp       string   `json:"timestamp"`
+	EnabledGroupIDs []string `json:"enabledGroupIds"`
+	BeforeHash      string   `json:"beforeHash"`           // 写入前内容的SHA-256
+	AfterHash       string   `json:"afterHash"`            // 写入后内容的SHA-256
+	RollbackOf      string   `json:"rollbackOf,omitempty"` // 回滚的目标记录ID（仅rollback）
+}
+
+// GroupChange 一次刷新中分组内容的变化记录
+// 条目列表和diff会被截断，Added/Removed/Changed始终为完整数量
+type GroupChange struct {
+	ID        string             `json:"id"`
+	GroupID   string             `json:"groupId"`
+	Timestamp string             `json:"timestamp"`
+	Source    string             `json:"source"` // 内容来源（URL或文件路径）
+	Added     int                `json:"added"`
+	Removed   int                `json:"removed"`
+	Changed   int                `json:"changed"`
+	Changes   hosts.EntryChanges `json:"changes"`
+	Diff      string             `json:"diff"`
+	Truncated bool               `json:"truncated"` // 条目列表或diff是否被截断
+}
+
+// SwitchRecord 一次自动启用或禁用分组的记录
+type SwitchRecord struct {
+	ID        string `json:"id"`
+	GroupID   string `json:"groupId"`
+	GroupName string `json:"groupName"`
+	Timestamp string `json:"timestamp"`
+	Enabled   bool   `json:"enabled"` // 切换后的状态
+	Trigger   string `json:"trigger"` // 触发方式：schedule、expiry
+	Reason    string `json:"reason"`
+}
+
+const (
+	// SwitchTriggerSchedule 由启用计划触发的切换
+	SwitchTriggerSchedule = "schedule"
+	// SwitchTriggerExpiry 临时启用到期触发的切换
+	SwitchTriggerExpiry = "expiry"
+)
+
+// ApplySnapshot 应用历史记录及写入前后的完整文件内容
+type ApplySnapshot struct {
+	ApplyRecord
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+const (
+	// DriftImport 将手动修改导入为新的分组后再应用
+	DriftImport = "import"
+	// DriftOverwrite 丢弃手动修改直接覆盖
+	DriftOverwrite = "overwrite"
+	// DriftAbort 放弃本次应用
+	DriftAbort = "abort"
+)
+
+// DriftReport 系统hosts文件中Ghost段被手动修改的检测结果
+type DriftReport struct {
+	Drifted      bool     `json:"drifted"`
+	ExpectedHash string   `json:"expectedHash"` // 最近一次写入时的摘要
+	CurrentHash  string   `json:"currentHash"`  // 当前文件中Ghost段的摘要
+	EditedLines  []string `json:"editedLines"`  // 应用时将会丢失的手动修改行
+}
+
+// LineOrigin 系统hosts文件中一行的来源
+type LineOrigin struct {
+	Line      int    `json:"line"` // 行号（从1开始）
+	Text      string `json:"text"`
+	Managed   bool   `json:"managed"`             // 是否位于Ghost段内
+	GroupID   string `json:"groupId,omitempty"`   // 来源分组ID
+	GroupName string `json:"groupName,omitempty"` // 来源分组名称
+}
+
+const (
+	// AuthNone 不使用认证
+	AuthNone = "none"
+	// AuthBasic HTTP基本认证
+	AuthBasic = "basic"
+	// AuthBearer Bearer令牌认证
+	AuthBearer = "bearer"
+)
+
+// SecretMask 返回给前端的凭据中用于替代敏感值的占位符
+const SecretMask = "********"
+
+// RemoteCredentials 远程分组的认证信息，单独保存在secrets.json中
+type RemoteCredentials struct {
+	AuthType   string            `json:"authType"`             // none、basic、bearer
+	Username   string            `json:"username,omitempty"`   // 基本认证用户名
+	Password   string            `json:"password,omitempty"`   // 基本认证密码
+	Token      string            `json:"token,omitempty"`      // Bearer令牌
+	Headers    map[string]string `json:"headers,omitempty"`    // 额外的请求头
+	ClientCert string            `json:"clientCert,omitempty"` // 客户端证书（PEM）
+	ClientKey  string            `json:"clientKey,omitempty"`  // 客户端私钥（PEM）
+}
+
+// Masked 返回隐藏了敏感值的副本，用于展示
+func (c *RemoteCredentials) Masked() *RemoteCredentials {
+	masked := *c
+	mask := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return SecretMask
+	}
+
+	masked.Password = mask(c.Password)
+	masked.Token = mask(c.Token)
+	masked.ClientKey = mask(c.ClientKey)
+	if c.Headers != nil {
+		masked.Headers = make(map[string]string, len(c.Headers))
+		for name, value := range c.Headers {
+			masked.Headers[name] = mask(value)
+		}
+	}
+	return &masked
+}
+
+// MergeMasked 将仍为占位符的敏感值替换为旧凭据中的原值
+func (c *RemoteCredentials) MergeMasked(old *RemoteCredentials) {
+	if old == nil {
+		return
+	}
+	if c.Password == SecretMask {
+		c.Password = old.Password
+	}
+	if c.Token == SecretMask {
+		c.Token = old.Token
+	}
+	if c.ClientKey == SecretMask {
+		c.ClientKey = old.ClientKey
+	}
+	for name, value := range c.Headers {
+		if value == SecretMask {
+			c.Headers[name] = old.Headers[name]
+		}
+	}
 }
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile 一组启用的分组，切换时只启用其中的分组并应用
+type Profile struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Groups      []string         `json:"groups"`    // 切换到该配置方案时启用的分组ID，其余分组禁用
+	Overrides   ProfileOverrides `json:"overrides"` // 切换时覆盖的全局设置
+	CreatedAt   string           `json:"createdAt"`
+	UpdatedAt   string           `json:"updatedAt"`
+}
+
+// ProfileOverrides 配置方案覆盖的全局设置，为空的字段使用保存的全局设置
+type ProfileOverrides struct {
+	ConflictPolicy string `json:"conflictPolicy,omitempty"` // 主机名冲突的处理策略：ignore、warn、refuse
+	MergeMode      string `json:"mergeMode,omitempty"`      // 分组合并方式：concat、dedupe
+}
+
+// Validate 校验配置方案的名称和覆盖设置
+func (p *Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+
+	switch p.Overrides.ConflictPolicy {
+	case "", ConflictPolicyIgnore, ConflictPolicyWarn, ConflictPolicyRefuse:
+	default:
+		return fmt.Errorf("invalid conflict policy: %s", p.Overrides.ConflictPolicy)
+	}
+	switch p.Overrides.MergeMode {
+	case "", MergeModeConcat, MergeModeDedupe:
+	default:
+		return fmt.Errorf("invalid merge mode: %s", p.Overrides.MergeMode)
+	}
+
+	return nil
+}
+
+// EffectiveConfig 返回应用时实际使用的配置：在保存的配置上叠加当前配置方案的覆盖设置
+// 覆盖设置只在应用时生效，不会写回保存的配置，切换到没有覆盖的方案时自然恢复原有设置
+func (m *HostManager) EffectiveConfig(config *AppConfig) *AppConfig {
+	effective := *config
+	for _, profile := range m.Profiles {
+		if config.ActiveProfile == "" || profile.Name != config.ActiveProfile {
+			continue
+		}
+		if profile.Overrides.ConflictPolicy != "" {
+			effective.ConflictPolicy = profile.Overrides.ConflictPolicy
+		}
+		if profile.Overrides.MergeMode != "" {
+			effective.MergeMode = profile.Overrides.MergeMode
+		}
+		break
+	}
+	return &effective
+}
+
+// ProfileExport 导出的配置方案，同时保存分组ID和名称，导入到其他机器时按名称匹配分组
+type ProfileExport struct {
+	Version  string          `json:"version"`
+	Profiles []ExportProfile `json:"profiles"`
+}
+
+// ExportProfile 导出的单个配置方案
+type ExportProfile struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Groups      []ExportGroupRef `json:"groups"`
+	Overrides   ProfileOverrides `json:"overrides"`
+}
+
+// ExportGroupRef 导出的分组引用
+type ExportGroupRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ProfileExportVersion 配置方案导出格式的版本
+const ProfileExportVersion = "1"
@@ -0,0 +1,185 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ghost/hosts"
+)
+
+const (
+	// SourceModeFallback 依次尝试各个来源，直到有一个成功（默认）
+	SourceModeFallback = "fallback"
+	// SourceModeMerge 获取所有来源并合并去重
+	SourceModeMerge = "merge"
+)
+
+// RemoteSource 远程分组的一个来源
+type RemoteSource struct {
+	URL          string       `json:"url"`
+	SourceFormat string       `json:"sourceFormat,omitempty"` // 覆盖分组的来源格式
+	Status       SourceStatus `json:"status"`
+}
+
+// SourceStatus 单个来源最近的获取状态，用于判断哪个镜像已经失效
+type SourceStatus struct {
+	LastChecked         string `json:"lastChecked,omitempty"` // 最后一次尝试获取的时间
+	LastSuccess         string `json:"lastSuccess,omitempty"` // 最后一次成功获取的时间
+	LastError           string `json:"lastError,omitempty"`   // 最后一次失败的错误信息
+	ConsecutiveFailures int    `json:"consecutiveFailures"`   // 连续失败次数
+}
+
+// Record 根据一次获取的结果更新来源状态
+func (s *SourceStatus) Record(err error, now time.Time) {
+	s.LastChecked = now.Format(time.RFC3339)
+	if err != nil {
+		s.LastError = err.Error()
+		s.ConsecutiveFailures++
+		return
+	}
+	s.LastSuccess = s.LastChecked
+	s.LastError = ""
+	s.ConsecutiveFailures = 0
+}
+
+// EffectiveSources 返回实际使用的来源列表，未配置Sources时使用URL作为唯一来源
+func (g *HostGroup) EffectiveSources() []RemoteSource {
+	if len(g.Sources) > 0 {
+		return g.Sources
+	}
+	if g.URL == "" {
+		return nil
+	}
+	return []RemoteSource{{URL: g.URL}}
+}
+
+// EffectiveSourceMode 返回实际生效的多来源模式
+func (g *HostGroup) EffectiveSourceMode() string {
+	if g.SourceMode == SourceModeMerge {
+		return SourceModeMerge
+	}
+	return SourceModeFallback
+}
+
+// CurrentSourceURL 返回当前内容来自的来源，旧数据没有记录时视为URL
+func (g *HostGroup) CurrentSourceURL() string {
+	if g.ContentSource != "" {
+		return g.ContentSource
+	}
+	return g.URL
+}
+
+// HasSource 判断分组是否包含指定URL的来源
+func (g *HostGroup) HasSource(url string) bool {
+	for _, source := range g.EffectiveSources() {
+		if source.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceFormatFor 返回指定来源实际使用的格式
+func (g *HostGroup) SourceFormatFor(url string) string {
+	for _, source := range g.Sources {
+		if source.URL == url && source.SourceFormat != "" {
+			return source.SourceFormat
+		}
+	}
+	return g.SourceFormat
+}
+
+// RecordSource 记录指定来源的获取结果，未配置Sources的分组只使用Health记录
+func (g *HostGroup) RecordSource(url string, err error, now time.Time) {
+	for i := range g.Sources {
+		if g.Sources[i].URL == url {
+			g.Sources[i].Status.Record(err, now)
+		}
+	}
+}
+
+// NormalizeSources 校验来源设置，并将URL同步为第一个来源
+func (g *HostGroup) NormalizeSources() error {
+	switch g.SourceMode {
+	case "", SourceModeFallback, SourceModeMerge:
+	default:
+		return fmt.Errorf("unsupported source mode: %s", g.SourceMode)
+	}
+
+	seen := make(map[string]bool, len(g.Sources))
+	for i := range g.Sources {
+		source := &g.Sources[i]
+		source.URL = strings.TrimSpace(source.URL)
+		if source.URL == "" {
+			return fmt.Errorf("source %d URL cannot be empty", i+1)
+		}
+		if seen[source.URL] {
+			return fmt.Errorf("duplicate source URL: %s", source.URL)
+		}
+		seen[source.URL] = true
+		if err := hosts.ValidateFormat(source.SourceFormat, ""); err != nil {
+			return fmt.Errorf("source %s: %w", source.URL, err)
+		}
+	}
+
+	if len(g.Sources) > 0 {
+		g.URL = g.Sources[0].URL
+	}
+	if g.EffectiveSourceMode() == SourceModeMerge && g.SHA256 != "" && len(g.Sources) > 1 {
+		return fmt.Errorf("a SHA-256 pin cannot be used when merging different sources")
+	}
+
+	return nil
+}
+
+// keepSourceStatus 按URL保留旧分组中各来源的状态
+func (g *HostGroup) keepSourceStatus(old *HostGroup) {
+	for i := range g.Sources {
+		for _, oldSource := range old.Sources {
+			if oldSource.URL == g.Sources[i].URL {
+				g.Sources[i].Status = oldSource.Status
+				break
+			}
+		}
+	}
+}
+
+// MergeRefresh 将基于旧副本完成的刷新结果合并到最新保存的分组，刷新期间修改的其他设置保持不变
+// 来源相关的设置在刷新期间被修改时刷新结果已经失效，不做任何修改并返回false
+func (g *HostGroup) MergeRefresh(refreshed *HostGroup) bool {
+	if !g.sameSource(refreshed) {
+		return false
+	}
+
+	g.Content = refreshed.Content
+	g.Issues = refreshed.Issues
+	g.LastUpdated = refreshed.LastUpdated
+	g.LastChecked = refreshed.LastChecked
+	g.ETag = refreshed.ETag
+	g.LastModified = refreshed.LastModified
+	g.ContentSource = refreshed.ContentSource
+	g.FileModTime = refreshed.FileModTime
+	g.FileSize = refreshed.FileSize
+	g.Health = refreshed.Health
+	g.keepSourceStatus(refreshed)
+	return true
+}
+
+// sameSource 判断两个分组获取和处理内容的设置是否相同
+func (g *HostGroup) sameSource(other *HostGroup) bool {
+	if g.IsRemote != other.IsRemote || g.IsFile != other.IsFile || g.URL != other.URL ||
+		g.FilePath != other.FilePath || g.EffectiveSourceMode() != other.EffectiveSourceMode() ||
+		g.SourceFormat != other.SourceFormat || g.SinkAddress != other.SinkAddress ||
+		g.SHA256 != other.SHA256 || g.PublicKey != other.PublicKey ||
+		g.EffectiveValidationPolicy() != other.EffectiveValidationPolicy() ||
+		len(g.Sources) != len(other.Sources) {
+		return false
+	}
+	for i := range g.Sources {
+		if g.Sources[i].URL != other.Sources[i].URL || g.Sources[i].SourceFormat != other.Sources[i].SourceFormat {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package remote
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ghost/models"
+)
+
+// applyCredentials 将认证信息和额外请求头添加到请求中
+func applyCredentials(req *http.Request, creds *models.RemoteCredentials) {
+	if creds == nil {
+		return
+	}
+
+	for name, value := range creds.Headers {
+		req.Header.Set(name, value)
+	}
+
+	switch creds.AuthType {
+	case models.AuthBasic:
+		req.SetBasicAuth(creds.Username, creds.Password)
+	case models.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+creds.Token)
+	}
+}
+
+// credentialsFor 返回请求target时可以使用的认证信息
+// 认证信息属于分组的主URL（未设置时为第一个来源），只发送给协议和主机都相同的地址，
+// 避免把令牌、请求头和客户端证书泄露给镜像或签名文件所在的其他服务器
+func credentialsFor(group *models.HostGroup, target string, creds *models.RemoteCredentials) *models.RemoteCredentials {
+	if creds == nil {
+		return nil
+	}
+
+	owner := group.URL
+	if owner == "" {
+		if sources := group.EffectiveSources(); len(sources) > 0 {
+			owner = sources[0].URL
+		}
+	}
+	if !sameOrigin(owner, target) {
+		return nil
+	}
+	return creds
+}
+
+// sameOrigin 判断两个URL的协议和主机（含端口）是否相同
+func sameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil || ua.Host == "" {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil || ub.Host == "" {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// maxRedirects 最多跟随的重定向次数，与net/http的默认值一致
+const maxRedirects = 10
+
+// checkRedirect 重定向到其他协议或主机时只保留通用请求头
+// net/http只会去掉Authorization等少数请求头，额外的自定义请求头同样可能包含令牌
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if !sameOrigin(via[0].URL.String(), req.URL.String()) {
+		header := make(http.Header)
+		for _, name := range []string{"User-Agent", "Accept-Encoding"} {
+			if value := req.Header.Get(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+		req.Header = header
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package remote
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding 请求时声明支持的压缩格式
+const acceptEncoding = "gzip, deflate, br"
+
+// checkContentType 拒绝明显不是hosts文件的内容类型，未声明类型时放行
+func checkContentType(url, contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	switch {
+	case mediaType == "text/html",
+		mediaType == "application/xhtml+xml",
+		strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"):
+		return &ContentTypeError{URL: url, ContentType: contentType}
+	}
+
+	return nil
+}
+
+// readBody 按照Content-Encoding解压响应内容，并限制解压后的大小
+// limit小于等于0表示不限制
+func readBody(url string, resp *http.Response, limit int64) ([]byte, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	// 未压缩时可以根据Content-Length提前拒绝
+	if limit > 0 && encoding == "" && resp.ContentLength > limit {
+		return nil, &SizeLimitError{URL: url, Limit: limit, Size: resp.ContentLength}
+	}
+
+	reader, err := decodeReader(resp.Body, encoding)
+	if errors.Is(err, errUnsupportedEncoding) {
+		return nil, &EncodingError{URL: url, Encoding: encoding}
+	}
+	if err != nil {
+		return nil, &EncodingError{URL: url, Encoding: encoding, Err: err}
+	}
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit+1)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		if encoding != "" && encoding != "identity" {
+			return nil, &EncodingError{URL: url, Encoding: encoding, Err: err}
+		}
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if limit > 0 && int64(len(body)) > limit {
+		return nil, &SizeLimitError{URL: url, Limit: limit, Size: -1}
+	}
+
+	return body, nil
+}
+
+// errUnsupportedEncoding 表示不支持的压缩格式
+var errUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// decodeReader 返回解压后的读取器
+func decodeReader(body io.Reader, encoding string) (io.Reader, error) {
+	switch encoding {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "br":
+		return brotli.NewReader(body), nil
+	case "deflate":
+		// 规范要求deflate为zlib格式，但部分服务器直接发送原始deflate数据
+		buffered := bufio.NewReader(body)
+		header, err := buffered.Peek(2)
+		if err == nil && isZlibHeader(header) {
+			return zlib.NewReader(buffered)
+		}
+		return flate.NewReader(buffered), nil
+	default:
+		return nil, errUnsupportedEncoding
+	}
+}
+
+// isZlibHeader 判断前两个字节是否为zlib头
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
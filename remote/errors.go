@@ -0,0 +1,96 @@
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+)
+
+// SizeLimitError 远程内容超过了允许的最大大小
+type SizeLimitError struct {
+	URL   string
+	Limit int64 // 允许的最大字节数
+	Size  int64 // 服务器声明的大小，未知时为-1
+}
+
+// Error 实现error接口
+func (e *SizeLimitError) Error() string {
+	if e.Size >= 0 {
+		return fmt.Sprintf("remote content from %s is %d bytes, exceeding the limit of %d bytes", e.URL, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("remote content from %s exceeds the limit of %d bytes", e.URL, e.Limit)
+}
+
+// ContentTypeError 远程内容的类型明显不是hosts文件（例如HTML页面或图片）
+type ContentTypeError struct {
+	URL         string
+	ContentType string
+}
+
+// Error 实现error接口
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("remote content from %s has unexpected content type %q", e.URL, e.ContentType)
+}
+
+// EncodingError 远程内容的压缩格式不受支持或无法解压
+type EncodingError struct {
+	URL      string
+	Encoding string
+	Err      error
+}
+
+// Error 实现error接口
+func (e *EncodingError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("remote content from %s uses unsupported encoding %q", e.URL, e.Encoding)
+	}
+	return fmt.Sprintf("failed to decode %s content from %s: %v", e.Encoding, e.URL, e.Err)
+}
+
+// Unwrap 返回底层错误
+func (e *EncodingError) Unwrap() error {
+	return e.Err
+}
+
+// isRetryable 判断错误是否值得重试
+// 只有可重试的状态码、连接失败、超时和连接中断会重试；证书、TLS握手、构造请求等
+// 无法通过重试解决的错误以及未知错误都直接返回，内容本身有问题时重试也没有意义
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.retryable()
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var alertErr tls.AlertError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &alertErr) || errors.As(err, &recordErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET)
+}
@@ -0,0 +1,216 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+
+	"ghost/models"
+)
+
+// SignatureSuffix 分离签名文件相对于内容URL的后缀
+const SignatureSuffix = ".sig"
+
+// IntegrityError 远程内容未通过完整性校验
+type IntegrityError struct {
+	URL    string
+	Reason string
+}
+
+// Error 实现error接口
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("integrity check failed for %s: %s", e.URL, e.Reason)
+}
+
+// publicKey 解析后的公钥，minisign公钥带有密钥ID
+type publicKey struct {
+	key      ed25519.PublicKey
+	keyID    []byte // minisign密钥ID，原始ed25519公钥时为nil
+	minisign bool
+}
+
+// ValidateIntegrity 校验分组的SHA-256固定值和公钥格式
+func ValidateIntegrity(group *models.HostGroup) error {
+	if group.SHA256 != "" {
+		if _, err := parseSHA256(group.SHA256); err != nil {
+			return err
+		}
+	}
+	if group.PublicKey != "" {
+		if _, err := parsePublicKey(group.PublicKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSHA256 解析十六进制的SHA-256值，允许带"sha256:"前缀
+func parseSHA256(pin string) ([]byte, error) {
+	pin = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(pin)), "sha256:")
+	sum, err := hex.DecodeString(pin)
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("invalid SHA-256 pin: expected %d hex characters", sha256.Size*2)
+	}
+	return sum, nil
+}
+
+// parsePublicKey 解析公钥，支持base64编码的原始ed25519公钥和minisign公钥（可包含注释行）
+func parsePublicKey(text string) (*publicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(lastLine(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	switch {
+	case len(data) == ed25519.PublicKeySize:
+		return &publicKey{key: ed25519.PublicKey(data)}, nil
+	case len(data) == 2+8+ed25519.PublicKeySize && string(data[:2]) == "Ed":
+		return &publicKey{key: ed25519.PublicKey(data[10:]), keyID: data[2:10], minisign: true}, nil
+	default:
+		return nil, fmt.Errorf("invalid public key: expected an ed25519 or minisign public key")
+	}
+}
+
+// verifyIntegrity 按照分组设置校验从sourceURL获取的内容，未设置固定值和公钥时直接通过
+// 设置了公钥时会从内容URL旁边获取分离签名
+func (rf *RemoteFetcher) verifyIntegrity(group *models.HostGroup, sourceURL string, content []byte, opts FetchOptions) error {
+	if group.SHA256 != "" {
+		expected, err := parseSHA256(group.SHA256)
+		if err != nil {
+			return &IntegrityError{URL: sourceURL, Reason: err.Error()}
+		}
+		actual := sha256.Sum256(content)
+		if subtle.ConstantTimeCompare(expected, actual[:]) != 1 {
+			return &IntegrityError{URL: sourceURL, Reason: fmt.Sprintf("SHA-256 mismatch (got %x)", actual)}
+		}
+	}
+
+	if group.PublicKey == "" {
+		return nil
+	}
+
+	key, err := parsePublicKey(group.PublicKey)
+	if err != nil {
+		return &IntegrityError{URL: sourceURL, Reason: err.Error()}
+	}
+
+	sigURL, err := signatureURL(sourceURL)
+	if err != nil {
+		return &IntegrityError{URL: sourceURL, Reason: err.Error()}
+	}
+
+	// 签名文件不使用条件请求，其余设置与内容请求一致
+	result, err := rf.Fetch(sigURL, FetchOptions{Credentials: credentialsFor(group, sigURL, opts.Credentials), Transport: opts.Transport})
+	if err != nil {
+		return &IntegrityError{URL: sourceURL, Reason: fmt.Sprintf("failed to fetch signature: %v", err)}
+	}
+
+	err = key.verify(content, []byte(result.Content))
+	if err != nil {
+		return &IntegrityError{URL: sourceURL, Reason: err.Error()}
+	}
+
+	return nil
+}
+
+// verify 校验签名，支持minisign签名文件和base64编码的原始ed25519签名
+func (k *publicKey) verify(content, sigFile []byte) error {
+	if bytes.Contains(sigFile, []byte("untrusted comment:")) {
+		return k.verifyMinisign(content, string(sigFile))
+	}
+	if k.minisign {
+		return fmt.Errorf("expected a minisign signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigFile)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid ed25519 signature")
+	}
+	if !ed25519.Verify(k.key, content, sig) {
+		return fmt.Errorf("signature does not match content")
+	}
+	return nil
+}
+
+// verifyMinisign 校验minisign格式的签名，包括对可信注释的全局签名
+func (k *publicKey) verifyMinisign(content []byte, sigFile string) error {
+	lines := strings.Split(strings.ReplaceAll(sigFile, "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return fmt.Errorf("incomplete minisign signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	algorithm, keyID, signature := string(sig[:2]), sig[2:10], sig[10:]
+
+	if k.keyID != nil && !bytes.Equal(keyID, k.keyID) {
+		return fmt.Errorf("signature was made with a different key (%X)", reverse(keyID))
+	}
+
+	// "ED"表示对内容的BLAKE2b-512摘要签名，"Ed"表示直接对内容签名
+	message := content
+	switch algorithm {
+	case "ED":
+		digest := blake2b.Sum512(content)
+		message = digest[:]
+	case "Ed":
+	default:
+		return fmt.Errorf("unsupported minisign algorithm %q", algorithm)
+	}
+	if !ed25519.Verify(k.key, message, signature) {
+		return fmt.Errorf("signature does not match content")
+	}
+
+	trustedComment, found := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !found {
+		return fmt.Errorf("minisign signature is missing the trusted comment")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign global signature")
+	}
+	signed := append(append([]byte{}, signature...), trustedComment...)
+	if !ed25519.Verify(k.key, signed, globalSig) {
+		return fmt.Errorf("trusted comment signature does not match")
+	}
+
+	return nil
+}
+
+// signatureURL 返回分离签名的地址：在URL路径后追加.sig，保留查询参数
+func signatureURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	u.Path += SignatureSuffix
+	if u.RawPath != "" {
+		u.RawPath += SignatureSuffix
+	}
+	return u.String(), nil
+}
+
+// lastLine 返回文本中最后一个非空行（minisign公钥文件第一行为注释）
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// reverse 返回字节逆序的副本，minisign以小端序显示密钥ID
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[len(b)-1-i]
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package remote
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+
+	"ghost/models"
+)
+
+// testKey 测试用的ed25519密钥和minisign密钥ID
+type testKey struct {
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+	keyID   []byte
+}
+
+// newTestKey 生成测试密钥
+func newTestKey(t *testing.T) *testKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKey{public: public, private: private, keyID: []byte{1, 2, 3, 4, 5, 6, 7, 8}}
+}
+
+// rawPublicKey 返回base64编码的原始ed25519公钥
+func (k *testKey) rawPublicKey() string {
+	return base64.StdEncoding.EncodeToString(k.public)
+}
+
+// minisignPublicKey 返回minisign格式的公钥文件内容
+func (k *testKey) minisignPublicKey() string {
+	data := append(append([]byte("Ed"), k.keyID...), k.public...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(data)
+}
+
+// rawSignature 返回base64编码的原始ed25519签名
+func (k *testKey) rawSignature(content []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(k.private, content))
+}
+
+// minisignSignature 返回minisign格式的签名文件，algorithm为Ed（直接签名）或ED（BLAKE2b摘要签名）
+func (k *testKey) minisignSignature(content []byte, algorithm string, keyID []byte, comment string) string {
+	message := content
+	if algorithm == "ED" {
+		digest := blake2b.Sum512(content)
+		message = digest[:]
+	}
+	signature := ed25519.Sign(k.private, message)
+	globalSig := ed25519.Sign(k.private, append(append([]byte{}, signature...), comment...))
+
+	sig := append(append([]byte(algorithm), keyID...), signature...)
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(sig) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSig) + "\n"
+}
+
+// TestVerifyIntegrity 测试SHA-256固定值、原始ed25519签名和minisign签名的校验
+func TestVerifyIntegrity(t *testing.T) {
+	key := newTestKey(t)
+	other := newTestKey(t)
+	content := []byte("0.0.0.0 ads.example.com\n")
+	tampered := []byte("0.0.0.0 ads.example.com\n1.2.3.4 bank.example.com\n")
+	sum := sha256.Sum256(content)
+
+	minisignED := key.minisignSignature(content, "ED", key.keyID, "timestamp:1700000000")
+	tests := []struct {
+		name      string
+		sha256    string
+		publicKey string
+		body      []byte
+		signature string
+		wantErr   bool
+	}{
+		{name: "no integrity settings", body: tampered},
+		{name: "sha256 pin", sha256: hex.EncodeToString(sum[:]), body: content},
+		{name: "sha256 pin with prefix", sha256: "sha256:" + strings.ToUpper(hex.EncodeToString(sum[:])), body: content},
+		{name: "sha256 mismatch", sha256: hex.EncodeToString(sum[:]), body: tampered, wantErr: true},
+		{name: "raw ed25519", publicKey: key.rawPublicKey(), body: content, signature: key.rawSignature(content)},
+		{name: "raw ed25519 tampered body", publicKey: key.rawPublicKey(), body: tampered, signature: key.rawSignature(content), wantErr: true},
+		{name: "raw ed25519 wrong key", publicKey: other.rawPublicKey(), body: content, signature: key.rawSignature(content), wantErr: true},
+		{name: "raw ed25519 missing signature", publicKey: key.rawPublicKey(), body: content, wantErr: true},
+		{name: "minisign Ed", publicKey: key.minisignPublicKey(), body: content, signature: key.minisignSignature(content, "Ed", key.keyID, "hosts")},
+		{name: "minisign ED", publicKey: key.minisignPublicKey(), body: content, signature: minisignED},
+		{name: "minisign with raw key", publicKey: key.rawPublicKey(), body: content, signature: minisignED},
+		{name: "minisign tampered body", publicKey: key.minisignPublicKey(), body: tampered, signature: minisignED, wantErr: true},
+		{
+			name:      "minisign tampered trusted comment",
+			publicKey: key.minisignPublicKey(),
+			body:      content,
+			signature: strings.Replace(minisignED, "timestamp:1700000000", "timestamp:1900000000", 1),
+			wantErr:   true,
+		},
+		{
+			name:      "minisign wrong key ID",
+			publicKey: key.minisignPublicKey(),
+			body:      content,
+			signature: key.minisignSignature(content, "ED", []byte{8, 7, 6, 5, 4, 3, 2, 1}, "hosts"),
+			wantErr:   true,
+		},
+		{name: "minisign key with raw signature", publicKey: key.minisignPublicKey(), body: content, signature: key.rawSignature(content), wantErr: true},
+		{
+			name:      "sha256 and signature",
+			sha256:    hex.EncodeToString(sum[:]),
+			publicKey: key.minisignPublicKey(),
+			body:      content,
+			signature: minisignED,
+		},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/hosts.txt"+SignatureSuffix || tt.signature == "" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(tt.signature))
+		}))
+
+		rf := NewRemoteFetcher()
+		rf.maxRetries = 0
+		group := &models.HostGroup{ID: "g", Name: "g", IsRemote: true, URL: server.URL + "/hosts.txt", SHA256: tt.sha256, PublicKey: tt.publicKey}
+		err := rf.verifyIntegrity(group, group.URL, tt.body, FetchOptions{})
+		server.Close()
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error=%t, got %v", tt.name, tt.wantErr, err)
+			continue
+		}
+		var integrityErr *IntegrityError
+		if err != nil && !errors.As(err, &integrityErr) {
+			t.Errorf("%s: expected IntegrityError, got %T", tt.name, err)
+		}
+	}
+}
+
+// TestValidateIntegrity 测试固定值和公钥格式的校验
+func TestValidateIntegrity(t *testing.T) {
+	key := newTestKey(t)
+	tests := []struct {
+		name    string
+		group   models.HostGroup
+		wantErr bool
+	}{
+		{"empty", models.HostGroup{}, false},
+		{"valid pin", models.HostGroup{SHA256: strings.Repeat("ab", sha256.Size)}, false},
+		{"short pin", models.HostGroup{SHA256: "abcd"}, true},
+		{"raw key", models.HostGroup{PublicKey: key.rawPublicKey()}, false},
+		{"minisign key", models.HostGroup{PublicKey: key.minisignPublicKey()}, false},
+		{"garbage key", models.HostGroup{PublicKey: "not a key"}, true},
+		{"short key", models.HostGroup{PublicKey: base64.StdEncoding.EncodeToString([]byte("short"))}, true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateIntegrity(&tt.group)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error=%t, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
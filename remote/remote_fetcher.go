@@ -1,91 +1,422 @@
 package remote
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
+	"log"
+	"math/rand/v2"
 	"net/http"
-	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"ghost/atomicfile"
+	"ghost/hosts"
 	"ghost/models"
 )
 
+const (
+	// DefaultMaxRetries 默认的最大重试次数
+	DefaultMaxRetries = 3
+	// DefaultRetryBaseDelay 默认的首次重试等待时间
+	DefaultRetryBaseDelay = 2 * time.Second
+	// DefaultRetryMaxDelay 默认的单次重试最长等待时间
+	DefaultRetryMaxDelay = 60 * time.Second
+)
+
+// DefaultFetchTimeout 默认的单次请求超时时间
+const DefaultFetchTimeout = 30 * time.Second
+
+// DefaultMaxRemoteSize 默认的远程内容最大大小（解压后）
+const DefaultMaxRemoteSize = 32 << 20
+
 // RemoteFetcher 用于从远程URL获取Host内容
 type RemoteFetcher struct {
-	httpClient *http.Client
-	userAgent  string
+	httpClient     *http.Client
+	transport      TransportOptions // 默认的代理、CA证书和超时设置
+	clients        map[clientKey]*http.Client
+	clientsMutex   sync.Mutex
+	userAgent      string
+	maxRetries     int           // 失败后的最大重试次数
+	retryBaseDelay time.Duration // 首次重试前的等待时间，之后按指数增长
+	retryMaxDelay  time.Duration // 单次重试等待时间上限
+	maxSize        int64         // 远程内容最大字节数（解压后）
+	// credentials 根据分组ID获取远程凭据，为nil时不使用认证
+	credentials func(groupID string) (*models.RemoteCredentials, error)
+	// ctx 取消后正在进行的请求和重试等待立即结束
+	ctx context.Context
 }
 
 // NewRemoteFetcher 创建新的远程获取器
 func NewRemoteFetcher() *RemoteFetcher {
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:       DefaultFetchTimeout,
+		CheckRedirect: checkRedirect,
 	}
 
 	return &RemoteFetcher{
-		httpClient: client,
-		userAgent:  "Ghost Host Manager/1.0",
+		httpClient:     client,
+		transport:      TransportOptions{Timeout: DefaultFetchTimeout},
+		clients:        make(map[clientKey]*http.Client),
+		userAgent:      "Ghost Host Manager/1.0",
+		maxRetries:     DefaultMaxRetries,
+		retryBaseDelay: DefaultRetryBaseDelay,
+		retryMaxDelay:  DefaultRetryMaxDelay,
+		maxSize:        DefaultMaxRemoteSize,
+		ctx:            context.Background(),
+	}
+}
+
+// NewRemoteFetcherWithConfig 根据应用程序配置创建远程获取器
+func NewRemoteFetcherWithConfig(config *models.AppConfig) *RemoteFetcher {
+	rf := NewRemoteFetcher()
+	if config == nil {
+		return rf
+	}
+
+	if config.FetchRetries >= 0 {
+		rf.maxRetries = config.FetchRetries
+	}
+	if config.RetryBaseDelay > 0 {
+		rf.retryBaseDelay = time.Duration(config.RetryBaseDelay) * time.Second
+	}
+	if rf.retryMaxDelay < rf.retryBaseDelay {
+		rf.retryMaxDelay = rf.retryBaseDelay
+	}
+	if config.MaxRemoteSize > 0 {
+		rf.maxSize = config.MaxRemoteSize << 20
 	}
+	rf.transport = rf.transport.Override(TransportOptions{
+		Proxy:    config.Proxy,
+		CABundle: config.CABundle,
+		Timeout:  time.Duration(config.FetchTimeout) * time.Second,
+	})
+
+	return rf
+}
+
+// SetContext 设置获取使用的上下文，上下文取消时正在进行的请求和重试等待立即结束
+func (rf *RemoteFetcher) SetContext(ctx context.Context) {
+	rf.ctx = ctx
+}
+
+// SetCredentialsProvider 设置按分组ID查找远程凭据的函数
+func (rf *RemoteFetcher) SetCredentialsProvider(provider func(groupID string) (*models.RemoteCredentials, error)) {
+	rf.credentials = provider
+}
+
+// StatusError 远程服务器返回了非预期的状态码
+type StatusError struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration // 服务器通过Retry-After要求的等待时间
+}
+
+// Error 实现error接口
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received status code %d from URL %s", e.StatusCode, e.URL)
+}
+
+// retryable 判断该状态码是否值得重试（服务器错误、限流、请求超时）
+func (e *StatusError) retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout
+}
+
+// FetchOptions 单次获取的可选参数
+type FetchOptions struct {
+	ETag         string // 上次响应的ETag，作为If-None-Match发送
+	LastModified string // 上次响应的Last-Modified，作为If-Modified-Since发送
+	Credentials  *models.RemoteCredentials
+	Transport    TransportOptions // 覆盖默认的代理、CA证书和超时设置，零值字段沿用默认值
+}
+
+// FetchResult 单次获取的结果
+type FetchResult struct {
+	Content      string
+	ETag         string
+	LastModified string
+	NotModified  bool // 服务器返回304，内容未变化
 }
 
 // FetchRemoteHosts 从指定URL获取远程Hosts内容
 func (rf *RemoteFetcher) FetchRemoteHosts(url string) (string, error) {
-	req, err := http.NewRequest("GET", url, nil)
+	result, err := rf.Fetch(url, FetchOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return result.Content, nil
+}
+
+// Fetch 从指定URL获取远程Hosts内容，提供缓存验证信息时发送条件请求
+// 连接失败、超时和可重试的状态码会按带抖动的指数退避重试，其他错误直接返回
+func (rf *RemoteFetcher) Fetch(url string, opts FetchOptions) (*FetchResult, error) {
+	var lastErr error
+	for attempt := 0; attempt <= rf.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := rf.backoff(attempt, lastErr)
+			log.Printf("Retrying %s in %s (attempt %d/%d): %v", url, delay.Round(time.Millisecond), attempt, rf.maxRetries, lastErr)
+			err := rf.wait(delay)
+			if err != nil {
+				return nil, fmt.Errorf("fetch from URL %s cancelled: %w (last error: %v)", url, err, lastErr)
+			}
+		}
+
+		result, err := rf.fetchOnce(url, opts)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+
+		if !isRetryable(err) {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+// wait 等待指定的时间，上下文取消时提前返回错误
+func (rf *RemoteFetcher) wait(delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-rf.ctx.Done():
+		return rf.ctx.Err()
+	}
+}
+
+// backoff 计算第attempt次重试前的等待时间：指数增长并在[delay/2, delay]范围内随机抖动
+func (rf *RemoteFetcher) backoff(attempt int, lastErr error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(lastErr, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, rf.retryMaxDelay)
+	}
+
+	delay := rf.retryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > rf.retryMaxDelay {
+		delay = rf.retryMaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+// fetchOnce 发送一次请求
+func (rf *RemoteFetcher) fetchOnce(url string, opts FetchOptions) (*FetchResult, error) {
+	req, err := http.NewRequestWithContext(rf.ctx, "GET", url, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", rf.userAgent)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	applyCredentials(req, opts.Credentials)
+	if opts.ETag != "" {
+		req.Header.Set("If-None-Match", opts.ETag)
+	}
+	if opts.LastModified != "" {
+		req.Header.Set("If-Modified-Since", opts.LastModified)
+	}
+
+	client, err := rf.clientFor(opts)
+	if err != nil {
+		return nil, err
+	}
 
-	resp, err := rf.httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch from URL %s: %w", url, err)
+		return nil, fmt.Errorf("failed to fetch from URL %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return &FetchResult{
+			ETag:         opts.ETag,
+			LastModified: opts.LastModified,
+			NotModified:  true,
+		}, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("received status code %d from URL %s", resp.StatusCode, url)
+		statusErr := &StatusError{URL: url, StatusCode: resp.StatusCode}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			statusErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, statusErr
+	}
+
+	err = checkContentType(url, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(url, resp, rf.maxSize)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
-	return string(body), nil
+	return &FetchResult{
+		Content:      string(body),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
 }
 
 // UpdateRemoteHostGroup 更新远程Host组
+// fallback模式下依次尝试各个来源，merge模式下合并所有来源；任何失败都保留原有内容
 func (rf *RemoteFetcher) UpdateRemoteHostGroup(group *models.HostGroup) error {
-	if !group.IsRemote || group.URL == "" {
+	sources := group.EffectiveSources()
+	if !group.IsRemote || len(sources) == 0 {
 		return fmt.Errorf("not a remote host group or URL is empty")
 	}
 
-	content, err := rf.FetchRemoteHosts(group.URL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch remote hosts: %w", err)
+	var opts FetchOptions
+	if rf.credentials != nil {
+		creds, err := rf.credentials(group.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load credentials: %w", err)
+		}
+		opts.Credentials = creds
+	}
+	opts.Transport = GroupTransport(group)
+
+	if group.EffectiveSourceMode() == models.SourceModeMerge && len(sources) > 1 {
+		return rf.updateMerged(group, sources, opts)
 	}
+	return rf.updateFallback(group, sources, opts)
+}
+
+// updateFallback 依次尝试各个来源，使用第一个成功的来源
+// 只对当前内容所来自的来源发送条件请求，服务器返回304时只更新最后检查时间
+func (rf *RemoteFetcher) updateFallback(group *models.HostGroup, sources []models.RemoteSource, opts FetchOptions) error {
+	var errs []error
+	for _, source := range sources {
+		sourceOpts := opts
+		if group.Content != "" && source.URL == group.CurrentSourceURL() {
+			sourceOpts.ETag = group.ETag
+			sourceOpts.LastModified = group.LastModified
+		}
+
+		result, content, issues, err := rf.fetchSource(group, source, sourceOpts)
+		now := time.Now()
+		group.RecordSource(source.URL, err, now)
+		if err != nil {
+			if len(sources) > 1 {
+				log.Printf("Source %s of group %s failed: %v", source.URL, group.Name, err)
+			}
+			errs = append(errs, err)
+			continue
+		}
 
-	// 不再验证获取到的内容是否符合hosts文件格式，直接更新
-	// 更新组内容
-	group.Content = content
-	group.LastUpdated = time.Now().Format(time.RFC3339)
+		group.LastChecked = now.Format(time.RFC3339)
+		group.ContentSource = source.URL
+		if result.NotModified {
+			return nil
+		}
+
+		// 更新组内容和缓存验证信息
+		group.Content = content
+		group.Issues = issues
+		group.ETag = result.ETag
+		group.LastModified = result.LastModified
+		group.LastUpdated = group.LastChecked
+		return nil
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return fmt.Errorf("all %d sources failed: %w", len(sources), errors.Join(errs...))
+}
 
-	// 更新RemoteConfig中的LastFetched字段
-	// 如果group是远程组，我们也可以更新它的最后获取时间
-	// 注意：这里假设HostGroup结构中没有嵌入RemoteConfig，所以只能更新LastUpdated字段
+// updateMerged 获取所有来源并按顺序合并去重，任一来源失败时保留原有内容
+// 合并模式下每次都完整下载所有来源，不使用条件请求
+func (rf *RemoteFetcher) updateMerged(group *models.HostGroup, sources []models.RemoteSource, opts FetchOptions) error {
+	parts := make([]hosts.Source, 0, len(sources))
+	var allIssues []hosts.Issue
+	var errs []error
+
+	for _, source := range sources {
+		_, content, issues, err := rf.fetchSource(group, source, opts)
+		group.RecordSource(source.URL, err, time.Now())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, issue := range issues {
+			issue.Source = source.URL
+			allIssues = append(allIssues, issue)
+		}
+		parts = append(parts, hosts.Source{ID: source.URL, Name: source.URL, Content: content})
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	group.LastChecked = now
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d sources failed: %w", len(errs), len(sources), errors.Join(errs...))
+	}
+
+	contents, resolutions := hosts.Dedupe(parts)
+	if len(resolutions) > 0 {
+		log.Printf("Group %s: %d hostnames mapped differently by its sources, kept the first source's mapping", group.Name, len(resolutions))
+	}
+
+	var sb strings.Builder
+	for i, content := range contents {
+		sb.WriteString(fmt.Sprintf("# Source: %s\n", parts[i].ID))
+		sb.WriteString(strings.TrimRight(content, "\n"))
+		sb.WriteString("\n")
+	}
+
+	group.Content = sb.String()
+	group.Issues = allIssues
+	group.ContentSource = ""
+	group.ETag = ""
+	group.LastModified = ""
+	group.LastUpdated = now
 
 	return nil
 }
 
-// DownloadToFile 下载远程内容到临时文件
+// fetchSource 获取单个来源并完成完整性校验、格式转换和内容校验
+// 返回的错误均包含来源URL
+func (rf *RemoteFetcher) fetchSource(group *models.HostGroup, source models.RemoteSource, opts FetchOptions) (*FetchResult, string, []hosts.Issue, error) {
+	opts.Credentials = credentialsFor(group, source.URL, opts.Credentials)
+	result, err := rf.Fetch(source.URL, opts)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to fetch remote hosts: %w", err)
+	}
+	if result.NotModified {
+		return result, "", nil, nil
+	}
+
+	err = rf.verifyIntegrity(group, source.URL, []byte(result.Content), opts)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	content, issues, err := group.ConvertContent(result.Content, group.SourceFormatFor(source.URL))
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("invalid remote content from %s: %w", source.URL, err)
+	}
+
+	return result, content, issues, nil
+}
+
+// DownloadToFile 下载远程内容并原子写入文件，下载或写入失败时不会留下不完整的文件
 func (rf *RemoteFetcher) DownloadToFile(url, filePath string) error {
 	content, err := rf.FetchRemoteHosts(url)
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(filePath, []byte(content), 0644)
+	err = atomicfile.WriteFile(filePath, []byte(content), 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write content to file: %w", err)
 	}
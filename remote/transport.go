@@ -0,0 +1,168 @@
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"ghost/models"
+)
+
+// TransportOptions 请求使用的代理、CA证书和超时设置
+type TransportOptions struct {
+	Proxy    string        // 代理地址（http、https、socks5），为空时使用环境变量，models.ProxyDirect表示不使用代理
+	CABundle string        // 额外信任的CA证书文件（PEM），为空时只使用系统证书
+	Timeout  time.Duration // 单次请求超时时间
+}
+
+// Override 返回用other中非零字段覆盖后的设置
+func (t TransportOptions) Override(other TransportOptions) TransportOptions {
+	if other.Proxy != "" {
+		t.Proxy = other.Proxy
+	}
+	if other.CABundle != "" {
+		t.CABundle = other.CABundle
+	}
+	if other.Timeout > 0 {
+		t.Timeout = other.Timeout
+	}
+	return t
+}
+
+// GroupTransport 返回分组中设置的覆盖项
+func GroupTransport(group *models.HostGroup) TransportOptions {
+	return TransportOptions{
+		Proxy:    group.Proxy,
+		CABundle: group.CABundle,
+		Timeout:  time.Duration(group.FetchTimeout) * time.Second,
+	}
+}
+
+// ValidateProxy 校验代理地址，空字符串和models.ProxyDirect视为合法
+func ValidateProxy(proxy string) error {
+	if proxy == "" || proxy == models.ProxyDirect {
+		return nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q (expected http, https or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("proxy URL %q has no host", proxy)
+	}
+
+	return nil
+}
+
+// clientKey 用于缓存HTTP客户端，设置相同的请求复用同一个客户端
+type clientKey struct {
+	proxy      string
+	caBundle   string
+	timeout    time.Duration
+	clientCert string
+	clientKey  string
+}
+
+// clientFor 返回适用于本次请求的HTTP客户端
+func (rf *RemoteFetcher) clientFor(opts FetchOptions) (*http.Client, error) {
+	settings := rf.transport.Override(opts.Transport)
+	key := clientKey{
+		proxy:    settings.Proxy,
+		caBundle: settings.CABundle,
+		timeout:  settings.Timeout,
+	}
+	if opts.Credentials != nil {
+		key.clientCert = opts.Credentials.ClientCert
+		key.clientKey = opts.Credentials.ClientKey
+	}
+
+	// 没有任何自定义设置时使用默认客户端
+	if key == (clientKey{timeout: rf.httpClient.Timeout}) {
+		return rf.httpClient, nil
+	}
+
+	rf.clientsMutex.Lock()
+	defer rf.clientsMutex.Unlock()
+
+	if client, exists := rf.clients[key]; exists {
+		return client, nil
+	}
+
+	transport, err := newTransport(key)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Timeout:       key.timeout,
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
+	}
+	rf.clients[key] = client
+	return client, nil
+}
+
+// newTransport 根据设置创建传输层
+func newTransport(key clientKey) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	switch key.proxy {
+	case "":
+		// 沿用环境变量中的代理设置
+	case models.ProxyDirect:
+		transport.Proxy = nil
+	default:
+		if err := ValidateProxy(key.proxy); err != nil {
+			return nil, err
+		}
+		proxyURL, _ := url.Parse(key.proxy)
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{}
+	if key.caBundle != "" {
+		pool, err := loadCABundle(key.caBundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if key.clientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(key.clientCert), []byte(key.clientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// loadCABundle 读取CA证书文件，并与系统证书合并
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+
+	return pool, nil
+}
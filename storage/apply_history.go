@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"ghost/atomicfile"
+	"ghost/models"
+)
+
+const (
+	// HistoryDir 应用历史快照目录
+	HistoryDir = "history"
+	// HistoryIndexFile 应用历史索引文件，只包含记录不包含文件内容
+	HistoryIndexFile = "history.json"
+	// DefaultMaxApplyHistory 默认保留的应用历史数量
+	DefaultMaxApplyHistory = 20
+)
+
+// SaveApplySnapshot 保存一次应用的快照，更新索引并清理超出数量的旧快照
+func (cs *ConfigStorage) SaveApplySnapshot(snapshot *models.ApplySnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(cs.historyPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	cs.historyMutex.Lock()
+	defer cs.historyMutex.Unlock()
+
+	records, err := cs.loadApplyIndex()
+	if err != nil {
+		return err
+	}
+
+	err = atomicfile.WriteFile(filepath.Join(cs.historyPath, snapshot.ID+".json"), data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write apply snapshot: %w", err)
+	}
+
+	// 同一ID再次保存时替换原有记录
+	records = slices.DeleteFunc(records, func(record models.ApplyRecord) bool {
+		return record.ID == snapshot.ID
+	})
+	records = append([]models.ApplyRecord{snapshot.ApplyRecord}, records...)
+	return cs.cleanupApplyHistory(records)
+}
+
+// ListApplyHistory 列出所有应用历史记录（最新的在前），只读取索引不读取快照内容
+func (cs *ConfigStorage) ListApplyHistory() ([]models.ApplyRecord, error) {
+	cs.historyMutex.Lock()
+	defer cs.historyMutex.Unlock()
+
+	return cs.loadApplyIndex()
+}
+
+// LoadApplySnapshot 加载指定ID的应用快照
+func (cs *ConfigStorage) LoadApplySnapshot(id string) (*models.ApplySnapshot, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid apply history ID: %s", id)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cs.historyPath, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("apply history %s not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot models.ApplySnapshot
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apply snapshot %s: %w", id, err)
+	}
+
+	return &snapshot, nil
+}
+
+// applySnapshotIDs 返回所有快照ID（最新的在前）
+// 快照ID以时间戳开头，因此按字典序倒序即为时间倒序
+func (cs *ConfigStorage) applySnapshotIDs() ([]string, error) {
+	files, err := os.ReadDir(cs.historyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, file := range files {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
+			ids = append(ids, strings.TrimSuffix(file.Name(), ".json"))
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// loadApplyIndex 读取应用历史索引，索引不存在或损坏时从快照重建
+// 调用方必须持有historyMutex
+func (cs *ConfigStorage) loadApplyIndex() ([]models.ApplyRecord, error) {
+	data, err := os.ReadFile(cs.historyIndex)
+	if err == nil {
+		var records []models.ApplyRecord
+		if json.Unmarshal(data, &records) == nil {
+			return records, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return cs.rebuildApplyIndex()
+}
+
+// rebuildApplyIndex 读取所有快照重建索引记录，只在索引缺失或损坏时使用
+func (cs *ConfigStorage) rebuildApplyIndex() ([]models.ApplyRecord, error) {
+	ids, err := cs.applySnapshotIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]models.ApplyRecord, 0, len(ids))
+	for _, id := range ids {
+		snapshot, err := cs.LoadApplySnapshot(id)
+		if err != nil {
+			continue
+		}
+		records = append(records, snapshot.ApplyRecord)
+	}
+
+	return records, nil
+}
+
+// cleanupApplyHistory 删除超出最大数量的旧快照，并将剩余记录写入索引
+// 调用方必须持有historyMutex
+func (cs *ConfigStorage) cleanupApplyHistory(records []models.ApplyRecord) error {
+	config, err := cs.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	maxHistory := config.MaxApplyHistory
+	if maxHistory <= 0 {
+		maxHistory = DefaultMaxApplyHistory
+	}
+
+	if len(records) > maxHistory {
+		records = records[:maxHistory]
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = atomicfile.WriteFile(cs.historyIndex, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write apply history index: %w", err)
+	}
+
+	ids, err := cs.applySnapshotIDs()
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[string]bool, len(records))
+	for _, record := range records {
+		keep[record.ID] = true
+	}
+	for _, id := range ids {
+		if !keep[id] {
+			// 记录错误但不停止整个过程
+			os.Remove(filepath.Join(cs.historyPath, id+".json"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"ghost/models"
+)
+
+// newTestStorage 创建使用临时目录的配置存储
+func newTestStorage(t *testing.T) *ConfigStorage {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	cs, err := NewConfigStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cs
+}
+
+// saveSnapshots 依次保存count个快照，返回按时间从旧到新的ID
+func saveSnapshots(t *testing.T, cs *ConfigStorage, count int) []string {
+	t.Helper()
+
+	ids := make([]string, count)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("20261018_%06d", i)
+		snapshot := &models.ApplySnapshot{
+			ApplyRecord: models.ApplyRecord{ID: ids[i], Kind: models.ApplyKindApply, EnabledGroupIDs: []string{"g"}},
+			Before:      fmt.Sprintf("before %d\n", i),
+			After:       fmt.Sprintf("after %d\n", i),
+		}
+		if err := cs.SaveApplySnapshot(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ids
+}
+
+// assertHistoryConsistent 检查索引中的记录与磁盘上的快照一一对应
+func assertHistoryConsistent(t *testing.T, name string, cs *ConfigStorage, want []string) {
+	t.Helper()
+
+	records, err := cs.ListApplyHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(records))
+	for i, record := range records {
+		got[i] = record.ID
+
+		snapshot, err := cs.LoadApplySnapshot(record.ID)
+		if err != nil {
+			t.Errorf("%s: index lists %s but its snapshot cannot be loaded: %v", name, record.ID, err)
+			continue
+		}
+		if snapshot.Kind != record.Kind || strings.Join(snapshot.EnabledGroupIDs, ",") != strings.Join(record.EnabledGroupIDs, ",") {
+			t.Errorf("%s: index record %+v does not match snapshot %+v", name, record, snapshot.ApplyRecord)
+		}
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("%s: expected history %v, got %v", name, want, got)
+	}
+
+	files, err := cs.applySnapshotIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(got)))
+	if strings.Join(files, ",") != strings.Join(got, ",") {
+		t.Errorf("%s: snapshot files %v do not match index %v", name, files, got)
+	}
+}
+
+// newestFirst 返回倒序的ID列表
+func newestFirst(ids []string) []string {
+	reversed := make([]string, len(ids))
+	for i, id := range ids {
+		reversed[len(ids)-1-i] = id
+	}
+	return reversed
+}
+
+// TestApplyHistoryIndex 测试索引与快照保持一致，超过上限时同时清理索引和快照
+func TestApplyHistoryIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		max   int
+		saves int
+		keep  int
+	}{
+		{"below limit", 5, 3, 3},
+		{"at limit", 5, 5, 5},
+		{"past limit", 5, 8, 5},
+		{"default limit", 0, DefaultMaxApplyHistory + 2, DefaultMaxApplyHistory},
+	}
+
+	for _, tt := range tests {
+		cs := newTestStorage(t)
+		config := defaultConfig()
+		config.MaxApplyHistory = tt.max
+		if err := cs.SaveConfig(config); err != nil {
+			t.Fatal(err)
+		}
+
+		ids := saveSnapshots(t, cs, tt.saves)
+		assertHistoryConsistent(t, tt.name, cs, newestFirst(ids[tt.saves-tt.keep:]))
+	}
+}
+
+// TestApplyHistoryIndexRebuild 测试索引丢失或损坏时从快照重建
+func TestApplyHistoryIndexRebuild(t *testing.T) {
+	tests := []struct {
+		name  string
+		index []byte // 为nil时删除索引
+	}{
+		{"missing index", nil},
+		{"corrupt index", []byte("{not json")},
+	}
+
+	for _, tt := range tests {
+		cs := newTestStorage(t)
+		ids := saveSnapshots(t, cs, 3)
+
+		var err error
+		if tt.index == nil {
+			err = os.Remove(cs.historyIndex)
+		} else {
+			err = os.WriteFile(cs.historyIndex, tt.index, 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertHistoryConsistent(t, tt.name, cs, newestFirst(ids))
+
+		// 重建后继续保存时索引保持完整，重复保存的ID不会产生重复记录
+		more := saveSnapshots(t, cs, 4)
+		assertHistoryConsistent(t, tt.name, cs, newestFirst(more))
+	}
+}
+
+// TestLoadApplySnapshotMissing 测试回滚到已被清理或不存在的快照时返回错误
+func TestLoadApplySnapshotMissing(t *testing.T) {
+	cs := newTestStorage(t)
+	config := defaultConfig()
+	config.MaxApplyHistory = 2
+	if err := cs.SaveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	ids := saveSnapshots(t, cs, 3)
+
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"pruned", ids[0]},
+		{"unknown", "20991231_000000"},
+		{"empty", ""},
+		{"path traversal", filepath.Join("..", "data")},
+	}
+
+	for _, tt := range tests {
+		if snapshot, err := cs.LoadApplySnapshot(tt.id); err == nil {
+			t.Errorf("%s: expected error, got snapshot %+v", tt.name, snapshot.ApplyRecord)
+		}
+	}
+
+	snapshot, err := cs.LoadApplySnapshot(ids[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Before != "before 2\n" || snapshot.After != "after 2\n" {
+		t.Errorf("unexpected snapshot content: %+v", snapshot)
+	}
+}
@@ -3,12 +3,15 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"ghost/atomicfile"
 	"ghost/models"
 )
 
@@ -21,9 +24,20 @@ const (
 
 // ConfigStorage 处理配置文件的读写
 type ConfigStorage struct {
-	configPath string
-	dataPath   string
-	mutex      sync.RWMutex
+	configPath    string
+	dataPath      string
+	backupPath    string
+	historyPath   string
+	historyIndex  string
+	historyMutex  sync.Mutex
+	secretsPath   string
+	secretsMutex  sync.Mutex
+	changesPath   string
+	changesMutex  sync.Mutex
+	switchLogPath string
+	switchMutex   sync.Mutex
+	mutex         sync.RWMutex
+	recoveries    []models.RecoveryEvent // 本次运行期间的损坏恢复记录
 }
 
 // NewConfigStorage 创建新的配置存储实例
@@ -46,43 +60,74 @@ func NewConfigStorage() (*ConfigStorage, error) {
 	}
 
 	return &ConfigStorage{
-		configPath: filepath.Join(appDataPath, ConfigFile),
-		dataPath:   filepath.Join(appDataPath, DataFile),
+		configPath:    filepath.Join(appDataPath, ConfigFile),
+		dataPath:      filepath.Join(appDataPath, DataFile),
+		backupPath:    backupPath,
+		historyPath:   filepath.Join(appDataPath, HistoryDir),
+		historyIndex:  filepath.Join(appDataPath, HistoryIndexFile),
+		secretsPath:   filepath.Join(appDataPath, SecretsFile),
+		changesPath:   filepath.Join(appDataPath, ChangesDir),
+		switchLogPath: filepath.Join(appDataPath, SwitchLogFile),
 	}, nil
 }
 
 // LoadConfig 加载应用程序配置
+// 配置文件损坏时自动从最新的有效备份恢复，没有可用备份时恢复为默认配置
 func (cs *ConfigStorage) LoadConfig() (*models.AppConfig, error) {
 	cs.mutex.RLock()
-	defer cs.mutex.RUnlock()
-
-	config := &models.AppConfig{}
-
-	// 设置默认值
-	config.AutoRefresh = false
-	config.RefreshInterval = 3600 // 1 hour
-	config.ActiveGroups = []string{}
-	config.BackupEnabled = true
-	config.MaxBackups = 10
+	data, err := os.ReadFile(cs.configPath)
+	cs.mutex.RUnlock()
 
 	// 如果配置文件不存在，返回默认配置
-	if _, err := os.Stat(cs.configPath); os.IsNotExist(err) {
-		return config, nil
+	if os.IsNotExist(err) {
+		return defaultConfig(), nil
 	}
-
-	data, err := os.ReadFile(cs.configPath)
 	if err != nil {
 		return nil, err
 	}
 
+	config := defaultConfig()
 	err = json.Unmarshal(data, config)
 	if err != nil {
-		return nil, err
+		fallback, _ := json.MarshalIndent(defaultConfig(), "", "  ")
+		data, err = cs.recoverCorruptFile(cs.configPath, isConfigBackup, validateConfig, fallback, err)
+		if err != nil {
+			return nil, err
+		}
+
+		config = defaultConfig()
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, err
+		}
 	}
 
 	return config, nil
 }
 
+// defaultConfig 返回默认的应用程序配置
+func defaultConfig() *models.AppConfig {
+	config := &models.AppConfig{}
+
+	// 设置默认值
+	config.AutoRefresh = false
+	config.RefreshInterval = 3600 // 1 hour
+	config.ActiveGroups = []string{}
+	config.BackupEnabled = true
+	config.MaxBackups = 10
+	config.ConflictPolicy = models.ConflictPolicyWarn
+	config.MergeMode = models.MergeModeConcat
+	config.MaxApplyHistory = DefaultMaxApplyHistory
+	config.FetchRetries = 3
+	config.RetryBaseDelay = 2
+	config.FetchTimeout = 30
+	config.MaxRemoteSize = 32
+	config.MaxChangeHistory = DefaultMaxChangeHistory
+	config.AutoApply = false
+	config.AutoApplyDelay = 2
+
+	return config
+}
+
 // SaveConfig 保存应用程序配置
 func (cs *ConfigStorage) SaveConfig(config *models.AppConfig) error {
 	cs.mutex.Lock()
@@ -93,16 +138,18 @@ func (cs *ConfigStorage) SaveConfig(config *models.AppConfig) error {
 		return err
 	}
 
-	return os.WriteFile(cs.configPath, data, 0644)
+	return atomicfile.WriteFile(cs.configPath, data, 0644)
 }
 
 // LoadHostManager 加载Host管理器数据
+// 数据文件损坏时自动从最新的有效备份恢复
 func (cs *ConfigStorage) LoadHostManager() (*models.HostManager, error) {
 	cs.mutex.RLock()
-	defer cs.mutex.RUnlock()
+	data, err := os.ReadFile(cs.dataPath)
+	cs.mutex.RUnlock()
 
 	// 如果数据文件不存在，返回空的HostManager
-	if _, err := os.Stat(cs.dataPath); os.IsNotExist(err) {
+	if os.IsNotExist(err) {
 		return &models.HostManager{
 			Config:    models.AppConfig{},
 			Groups:    []models.HostGroup{},
@@ -111,16 +158,25 @@ func (cs *ConfigStorage) LoadHostManager() (*models.HostManager, error) {
 			UpdatedAt: time.Now().Format(time.RFC3339),
 		}, nil
 	}
-
-	data, err := os.ReadFile(cs.dataPath)
 	if err != nil {
 		return nil, err
 	}
 
 	var manager models.HostManager
-	err = json.Unmarshal(data, &manager)
+	err = validateHostManager(data)
+	if err == nil {
+		err = json.Unmarshal(data, &manager)
+	}
 	if err != nil {
-		return nil, err
+		data, err = cs.recoverCorruptFile(cs.dataPath, isDataBackup, validateHostManager, nil, err)
+		if err != nil {
+			return nil, err
+		}
+
+		manager = models.HostManager{}
+		if err := json.Unmarshal(data, &manager); err != nil {
+			return nil, err
+		}
 	}
 
 	return &manager, nil
@@ -138,7 +194,141 @@ func (cs *ConfigStorage) SaveHostManager(manager *models.HostManager) error {
 		return err
 	}
 
-	return os.WriteFile(cs.dataPath, data, 0644)
+	return atomicfile.WriteFile(cs.dataPath, data, 0644)
+}
+
+// Recoveries 返回本次运行期间发生的所有损坏恢复记录
+func (cs *ConfigStorage) Recoveries() []models.RecoveryEvent {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	events := make([]models.RecoveryEvent, len(cs.recoveries))
+	copy(events, cs.recoveries)
+	return events
+}
+
+// recoverCorruptFile 处理损坏的文件：将其移到一旁保留，然后用最新的有效备份替换
+// 没有有效备份时，若提供了fallback则写入fallback，否则返回错误
+func (cs *ConfigStorage) recoverCorruptFile(path string, isCandidate func(string) bool, validate func([]byte) error, fallback []byte, parseErr error) ([]byte, error) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	// 其他调用者可能已经完成了恢复
+	data, err := os.ReadFile(path)
+	if err == nil && validate(data) == nil {
+		return data, nil
+	}
+
+	event := models.RecoveryEvent{
+		File:      filepath.Base(path),
+		Reason:    parseErr.Error(),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	// 保留损坏的文件以便排查
+	corruptPath := path + ".corrupt-" + time.Now().Format("20060102_150405")
+	if err := os.Rename(path, corruptPath); err == nil {
+		event.CorruptCopy = filepath.Base(corruptPath)
+	}
+
+	restored, source := cs.newestValidBackup(isCandidate, validate)
+	if restored == nil {
+		if fallback == nil {
+			return nil, fmt.Errorf("%s is corrupt and no valid backup was found: %w", filepath.Base(path), parseErr)
+		}
+		restored, source = fallback, "defaults"
+	}
+
+	if err := atomicfile.WriteFile(path, restored, 0644); err != nil {
+		return nil, fmt.Errorf("failed to restore %s: %w", filepath.Base(path), err)
+	}
+
+	event.RestoredFrom = source
+	cs.recoveries = append(cs.recoveries, event)
+	log.Printf("Recovered corrupt %s from %s (corrupt copy: %s)", event.File, source, event.CorruptCopy)
+
+	return restored, nil
+}
+
+// newestValidBackup 按修改时间从新到旧查找第一个通过校验的备份文件
+func (cs *ConfigStorage) newestValidBackup(isCandidate func(string) bool, validate func([]byte) error) ([]byte, string) {
+	files, err := os.ReadDir(cs.backupPath)
+	if err != nil {
+		return nil, ""
+	}
+
+	var candidates []os.FileInfo
+	for _, file := range files {
+		if file.IsDir() || !isCandidate(file.Name()) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, info)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ModTime().After(candidates[j].ModTime())
+	})
+
+	for _, info := range candidates {
+		data, err := os.ReadFile(filepath.Join(cs.backupPath, info.Name()))
+		if err != nil || validate(data) != nil {
+			continue
+		}
+		return data, info.Name()
+	}
+
+	return nil, ""
+}
+
+// isConfigBackup 判断文件是否为配置备份
+func isConfigBackup(name string) bool {
+	return strings.HasPrefix(name, "config_") && filepath.Ext(name) == ".json"
+}
+
+// isDataBackup 判断文件是否为数据备份
+func isDataBackup(name string) bool {
+	return !strings.HasPrefix(name, "config_") && filepath.Ext(name) == ".json"
+}
+
+// validateConfig 校验内容是否为有效的配置文件，null等非对象内容视为无效
+func validateConfig(data []byte) error {
+	if _, err := decodeObject(data); err != nil {
+		return err
+	}
+	var config models.AppConfig
+	return json.Unmarshal(data, &config)
+}
+
+// validateHostManager 校验内容是否为有效的数据文件
+// 除了能够解析之外还要求包含groups和version字段，避免把null或{}当作有效数据而丢失所有分组
+func validateHostManager(data []byte) error {
+	fields, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{"groups", "version"} {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("data file is missing the %q field", name)
+		}
+	}
+	var manager models.HostManager
+	return json.Unmarshal(data, &manager)
+}
+
+// decodeObject 将内容解析为JSON对象，内容为null或不是对象时返回错误
+func decodeObject(data []byte) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	return fields, nil
 }
 
 // BackupData 创建数据文件备份
@@ -159,7 +349,7 @@ func (cs *ConfigStorage) BackupData() error {
 	}
 
 	// 写入备份
-	err = os.WriteFile(backupFile, data, 0644)
+	err = atomicfile.WriteFile(backupFile, data, 0644)
 	if err != nil {
 		return err
 	}
@@ -186,7 +376,7 @@ func (cs *ConfigStorage) BackupConfig() error {
 	}
 
 	// 写入备份
-	err = os.WriteFile(backupFile, data, 0644)
+	err = atomicfile.WriteFile(backupFile, data, 0644)
 	if err != nil {
 		return err
 	}
@@ -265,7 +455,7 @@ func (cs *ConfigStorage) RestoreData(backupFileName string) error {
 	}
 
 	// 写入到 data.json
-	err = os.WriteFile(cs.dataPath, backupData, 0644)
+	err = atomicfile.WriteFile(cs.dataPath, backupData, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to restore data: %w", err)
 	}
@@ -328,7 +518,7 @@ func (cs *ConfigStorage) BackupRawSystemHosts(systemHostPath string) error {
 	}
 
 	// 写入备份
-	err = os.WriteFile(backupFile, content, 0644)
+	err = atomicfile.WriteFile(backupFile, content, 0644)
 	if err != nil {
 		return err
 	}
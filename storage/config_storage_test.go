@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	// validData 包含一个分组的有效数据文件，%s为分组名称
+	validData = `{"groups":[{"id":"g","name":"%s","content":"10.0.0.1 api.local","enabled":true}],"version":"1.0.0"}`
+	// truncatedData 写入中断后被截断的数据文件
+	truncatedData = `{"groups":[{"id":"g","name":"Dev","cont`
+)
+
+// TestValidateHostManager 测试数据文件的校验，能解析但缺少必需字段的内容也视为无效
+func TestValidateHostManager(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{"valid", fmt.Sprintf(validData, "Dev"), true},
+		{"empty groups", `{"groups":[],"version":"1.0.0"}`, true},
+		{"truncated", truncatedData, false},
+		{"empty file", "", false},
+		{"null", "null", false},
+		{"empty object", "{}", false},
+		{"array", "[]", false},
+		{"missing groups", `{"version":"1.0.0"}`, false},
+		{"missing version", `{"groups":[]}`, false},
+		{"wrong groups type", `{"groups":{},"version":"1.0.0"}`, false},
+	}
+
+	for _, tt := range tests {
+		err := validateHostManager([]byte(tt.data))
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%t, got error %v", tt.name, tt.valid, err)
+		}
+	}
+}
+
+// writeBackup 写入一个数据备份并设置修改时间
+func writeBackup(t *testing.T, cs *ConfigStorage, name, data string, age time.Duration) {
+	t.Helper()
+	path := filepath.Join(cs.backupPath, name)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLoadHostManagerRecovery 测试数据文件损坏时从最新的有效备份恢复，并保留损坏的文件
+func TestLoadHostManagerRecovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		backups bool
+		want    string // 恢复后的分组名称，为空时期望返回错误
+	}{
+		{"truncated json", truncatedData, true, "Newest good"},
+		{"missing fields", `{"config":{}}`, true, "Newest good"},
+		{"null", "null", true, "Newest good"},
+		{"no valid backup", truncatedData, false, ""},
+	}
+
+	for _, tt := range tests {
+		cs := newTestStorage(t)
+		if err := os.WriteFile(cs.dataPath, []byte(tt.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if tt.backups {
+			writeBackup(t, cs, "2026-10-16_10-00-00.json", fmt.Sprintf(validData, "Older good"), 3*time.Hour)
+			writeBackup(t, cs, "2026-10-17_10-00-00.json", fmt.Sprintf(validData, "Newest good"), 2*time.Hour)
+			writeBackup(t, cs, "2026-10-18_10-00-00.json", `{"config":{}}`, time.Hour)
+			writeBackup(t, cs, "2026-10-18_11-00-00.json", truncatedData, 0)
+		}
+		// 配置备份不能被当作数据备份
+		writeBackup(t, cs, "config_20261018_120000.json", `{"groups":[],"version":"1.0.0"}`, 0)
+
+		manager, err := cs.LoadHostManager()
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: expected error without a valid backup, got %+v", tt.name, manager)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: recovery failed: %v", tt.name, err)
+			continue
+		}
+		if len(manager.Groups) != 1 || manager.Groups[0].Name != tt.want {
+			t.Errorf("%s: expected groups from %q, got %+v", tt.name, tt.want, manager.Groups)
+		}
+
+		recoveries := cs.Recoveries()
+		if len(recoveries) != 1 || recoveries[0].RestoredFrom != "2026-10-17_10-00-00.json" {
+			t.Errorf("%s: unexpected recovery events %+v", tt.name, recoveries)
+			continue
+		}
+		corrupt, err := os.ReadFile(filepath.Join(filepath.Dir(cs.dataPath), recoveries[0].CorruptCopy))
+		if err != nil || string(corrupt) != tt.data {
+			t.Errorf("%s: expected corrupt copy to be kept, got %q (%v)", tt.name, corrupt, err)
+		}
+
+		// 恢复后的文件可以直接加载，不会再次恢复
+		if _, err := cs.LoadHostManager(); err != nil || len(cs.Recoveries()) != 1 {
+			t.Errorf("%s: expected restored file to load cleanly, got %v with %d recoveries", tt.name, err, len(cs.Recoveries()))
+		}
+	}
+}
+
+// TestLoadConfigRecovery 测试配置文件损坏且没有备份时恢复为默认配置
+func TestLoadConfigRecovery(t *testing.T) {
+	cs := newTestStorage(t)
+	if err := os.WriteFile(cs.configPath, []byte(`{"mergeMode":"dedupe",`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := cs.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MaxApplyHistory != DefaultMaxApplyHistory || config.MergeMode != defaultConfig().MergeMode {
+		t.Errorf("expected default config, got %+v", config)
+	}
+	if recoveries := cs.Recoveries(); len(recoveries) != 1 || recoveries[0].RestoredFrom != "defaults" {
+		t.Errorf("unexpected recovery events %+v", recoveries)
+	}
+}
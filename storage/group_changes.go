@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"ghost/atomicfile"
+	"ghost/models"
+)
+
+const (
+	// ChangesDir 分组内容变化记录目录，每个分组一个文件
+	ChangesDir = "changes"
+	// DefaultMaxChangeHistory 默认每个分组保留的变化记录数量
+	DefaultMaxChangeHistory = 50
+)
+
+// SaveGroupChange 追加一条分组内容变化记录，并删除超出数量的旧记录
+func (cs *ConfigStorage) SaveGroupChange(change *models.GroupChange) error {
+	cs.changesMutex.Lock()
+	defer cs.changesMutex.Unlock()
+
+	changes, err := cs.loadGroupChanges(change.GroupID)
+	if err != nil {
+		return err
+	}
+
+	maxHistory := DefaultMaxChangeHistory
+	if config, err := cs.LoadConfig(); err == nil && config.MaxChangeHistory > 0 {
+		maxHistory = config.MaxChangeHistory
+	}
+
+	// 最新的记录在前
+	changes = append([]models.GroupChange{*change}, changes...)
+	if len(changes) > maxHistory {
+		changes = changes[:maxHistory]
+	}
+
+	data, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(cs.changesPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = atomicfile.WriteFile(cs.groupChangesPath(change.GroupID), data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write group changes: %w", err)
+	}
+
+	return nil
+}
+
+// ListGroupChanges 列出指定分组的内容变化记录（最新的在前）
+func (cs *ConfigStorage) ListGroupChanges(groupID string) ([]models.GroupChange, error) {
+	cs.changesMutex.Lock()
+	defer cs.changesMutex.Unlock()
+
+	return cs.loadGroupChanges(groupID)
+}
+
+// DeleteGroupChanges 删除指定分组的所有变化记录
+func (cs *ConfigStorage) DeleteGroupChanges(groupID string) error {
+	cs.changesMutex.Lock()
+	defer cs.changesMutex.Unlock()
+
+	if groupID == "" || filepath.Base(groupID) != groupID {
+		return fmt.Errorf("invalid group ID: %s", groupID)
+	}
+
+	err := os.Remove(cs.groupChangesPath(groupID))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// loadGroupChanges 读取分组的变化记录文件
+func (cs *ConfigStorage) loadGroupChanges(groupID string) ([]models.GroupChange, error) {
+	if groupID == "" || filepath.Base(groupID) != groupID {
+		return nil, fmt.Errorf("invalid group ID: %s", groupID)
+	}
+
+	data, err := os.ReadFile(cs.groupChangesPath(groupID))
+	if os.IsNotExist(err) {
+		return []models.GroupChange{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []models.GroupChange
+	err = json.Unmarshal(data, &changes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group changes for %s: %w", groupID, err)
+	}
+
+	return changes, nil
+}
+
+// groupChangesPath 返回分组变化记录文件的路径
+func (cs *ConfigStorage) groupChangesPath(groupID string) string {
+	return filepath.Join(cs.changesPath, groupID+".json")
+}
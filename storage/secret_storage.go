@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"ghost/atomicfile"
+	"ghost/models"
+)
+
+// SecretsFile 远程分组凭据文件，与data.json分开保存，不参与备份和导出
+// 在Windows上0600权限不起作用，文件的访问控制依赖用户目录（%USERPROFILE%）默认的ACL
+const SecretsFile = "secrets.json"
+
+// LoadCredentials 获取指定分组的远程凭据，不存在时返回nil
+func (cs *ConfigStorage) LoadCredentials(groupID string) (*models.RemoteCredentials, error) {
+	cs.secretsMutex.Lock()
+	defer cs.secretsMutex.Unlock()
+
+	secrets, err := cs.loadSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	creds, exists := secrets[groupID]
+	if !exists {
+		return nil, nil
+	}
+	return &creds, nil
+}
+
+// SaveCredentials 保存指定分组的远程凭据
+func (cs *ConfigStorage) SaveCredentials(groupID string, creds *models.RemoteCredentials) error {
+	cs.secretsMutex.Lock()
+	defer cs.secretsMutex.Unlock()
+
+	secrets, err := cs.loadSecrets()
+	if err != nil {
+		return err
+	}
+
+	secrets[groupID] = *creds
+	return cs.saveSecrets(secrets)
+}
+
+// DeleteCredentials 删除指定分组的远程凭据
+func (cs *ConfigStorage) DeleteCredentials(groupID string) error {
+	cs.secretsMutex.Lock()
+	defer cs.secretsMutex.Unlock()
+
+	secrets, err := cs.loadSecrets()
+	if err != nil {
+		return err
+	}
+
+	if _, exists := secrets[groupID]; !exists {
+		return nil
+	}
+	delete(secrets, groupID)
+	return cs.saveSecrets(secrets)
+}
+
+// loadSecrets 读取凭据文件
+func (cs *ConfigStorage) loadSecrets() (map[string]models.RemoteCredentials, error) {
+	secrets := make(map[string]models.RemoteCredentials)
+
+	data, err := os.ReadFile(cs.secretsPath)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	err = json.Unmarshal(data, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets file: %w", err)
+	}
+
+	return secrets, nil
+}
+
+// saveSecrets 写入凭据文件，并确保只有当前用户可以读写
+// Windows上Chmod只能切换只读属性，不会限制其他用户访问，见SecretsFile的说明
+func (cs *ConfigStorage) saveSecrets(secrets map[string]models.RemoteCredentials) error {
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = atomicfile.WriteFile(cs.secretsPath, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+
+	// 已存在的文件会保留原有权限，这里强制收紧
+	return os.Chmod(cs.secretsPath, 0600)
+}
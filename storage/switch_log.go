@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"ghost/atomicfile"
+	"ghost/models"
+)
+
+const (
+	// SwitchLogFile 分组自动切换记录文件
+	SwitchLogFile = "switches.json"
+	// MaxSwitchLog 保留的自动切换记录数量
+	MaxSwitchLog = 200
+)
+
+// SaveSwitchRecord 追加一条自动切换记录，并删除超出数量的旧记录
+func (cs *ConfigStorage) SaveSwitchRecord(record *models.SwitchRecord) error {
+	cs.switchMutex.Lock()
+	defer cs.switchMutex.Unlock()
+
+	records, err := cs.loadSwitchLog()
+	if err != nil {
+		return err
+	}
+
+	// 最新的记录在前
+	records = append([]models.SwitchRecord{*record}, records...)
+	if len(records) > MaxSwitchLog {
+		records = records[:MaxSwitchLog]
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = atomicfile.WriteFile(cs.switchLogPath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write switch log: %w", err)
+	}
+
+	return nil
+}
+
+// ListSwitchLog 列出自动切换记录（最新的在前）
+func (cs *ConfigStorage) ListSwitchLog() ([]models.SwitchRecord, error) {
+	cs.switchMutex.Lock()
+	defer cs.switchMutex.Unlock()
+
+	return cs.loadSwitchLog()
+}
+
+// loadSwitchLog 读取自动切换记录文件
+func (cs *ConfigStorage) loadSwitchLog() ([]models.SwitchRecord, error) {
+	data, err := os.ReadFile(cs.switchLogPath)
+	if os.IsNotExist(err) {
+		return []models.SwitchRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []models.SwitchRecord
+	err = json.Unmarshal(data, &records)
+	if err != nil {
+		return nil, fmt.Errorf("invalid switch log: %w", err)
+	}
+
+	return records, nil
+}
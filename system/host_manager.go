@@ -2,12 +2,17 @@ package system
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"time"
 
+	"ghost/atomicfile"
+	"ghost/hosts"
+	"ghost/models"
 	"ghost/permissions"
 	"ghost/storage"
 )
@@ -17,8 +22,20 @@ const (
 	GhostSectionStart = "# >>> Ghost Host Entries"
 	// GhostSectionEnd Ghost标记段结束
 	GhostSectionEnd = "# <<< Ghost Host Entries"
+
+	// sectionManagedNote Ghost段开头的说明注释
+	sectionManagedNote = "# This section is managed by Ghost - Host Manager"
+	// sectionPreservedNote Ghost段开头的说明注释
+	sectionPreservedNote = "# Changes made outside this section will be preserved"
+	// generatedAtPrefix 生成时间注释前缀
+	generatedAtPrefix = "# Generated at: "
+	// contestedHeader 争用说明的标题行
+	contestedHeader = "# Contested hostnames (resolved by group priority):"
 )
 
+// resolutionPattern 匹配去重合并时生成的争用说明行，格式见hosts.Resolution.String
+var resolutionPattern = regexp.MustCompile(`^#   (\S+) -> (\S+) from .+ \(overrides: .+\)$`)
+
 // HostManager 系统hosts文件管理器
 type HostManager struct {
 	SystemHostPath string
@@ -42,6 +59,11 @@ func GetSystemHostsPath() string {
 	}
 }
 
+// HostsPath 返回系统hosts文件路径
+func (hm *HostManager) HostsPath() string {
+	return hm.SystemHostPath
+}
+
 // ReadSystemHosts 读取系统hosts文件内容
 func (hm *HostManager) ReadSystemHosts() (string, error) {
 	content, err := os.ReadFile(hm.SystemHostPath)
@@ -52,10 +74,21 @@ func (hm *HostManager) ReadSystemHosts() (string, error) {
 	return string(content), nil
 }
 
+// ReadUnmanagedHosts 读取系统hosts文件中不由Ghost管理的部分（即Ghost段以外的内容）
+func (hm *HostManager) ReadUnmanagedHosts() (string, error) {
+	content, err := hm.ReadSystemHosts()
+	if err != nil {
+		return "", err
+	}
+
+	return hm.removeGhostEntries(content)
+}
+
 // WriteSystemHosts 写入系统hosts文件内容
+// 通过临时文件加重命名的方式原子替换，写入失败时原文件保持不变
 func (hm *HostManager) WriteSystemHosts(content string) error {
 	// 写入新内容
-	err := os.WriteFile(hm.SystemHostPath, []byte(content), 0644)
+	err := atomicfile.WriteFile(hm.SystemHostPath, []byte(content), 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write system hosts file: %w", err)
 	}
@@ -96,37 +129,28 @@ func (hm *HostManager) CreateBackup() string {
 	return ""
 }
 
-// ApplyHostGroups 将指定的HostGroups应用到系统hosts文件
-func (hm *HostManager) ApplyHostGroups(hostGroups []map[string]interface{}) error {
-	// 读取当前系统hosts文件内容
-	currentContent, err := hm.ReadSystemHosts()
-	if err != nil {
-		return fmt.Errorf("failed to read current system hosts: %w", err)
-	}
-
+// RenderHostGroups 根据当前系统hosts内容生成应用指定HostGroups之后的完整内容，不写入文件
+// hostGroups应已按优先级从高到低排序；mergeMode为MergeModeDedupe时，同一主机名只保留优先级最高的分组中的映射
+func (hm *HostManager) RenderHostGroups(currentContent string, hostGroups []map[string]interface{}, mergeMode string) (string, error) {
 	// 移除之前的Ghost段
 	contentWithoutGhost, err := hm.removeGhostEntries(currentContent)
 	if err != nil {
-		return fmt.Errorf("failed to remove previous ghost entries: %w", err)
+		return "", fmt.Errorf("failed to remove previous ghost entries: %w", err)
 	}
 
-	// 准备新的Ghost段内容
-	var ghostContent strings.Builder
-	ghostContent.WriteString(fmt.Sprintf("\n%s\n", GhostSectionStart))
-	ghostContent.WriteString("# This section is managed by Ghost - Host Manager\n")
-	ghostContent.WriteString("# Changes made outside this section will be preserved\n")
-	ghostContent.WriteString("# Generated at: " + time.Now().Format(time.RFC3339) + "\n\n")
-
-	// 添加启用的host组内容
+	// 收集启用的host组
+	var ids, names, contents []string
+	var sources []hosts.Source
 	for _, group := range hostGroups {
 		enabled, ok := group["enabled"].(bool)
 		if !ok || !enabled {
 			continue
 		}
 
+		id, _ := group["id"].(string)
 		name, _ := group["name"].(string)
 		if name == "" {
-			name, _ = group["id"].(string)
+			name = id
 		}
 
 		content, ok := group["content"].(string)
@@ -134,12 +158,42 @@ func (hm *HostManager) ApplyHostGroups(hostGroups []map[string]interface{}) erro
 			continue
 		}
 
-		ghostContent.WriteString(fmt.Sprintf("# Start of group: %s\n", name))
+		ids = append(ids, id)
+		names = append(names, name)
+		contents = append(contents, content)
+		sources = append(sources, hosts.Source{ID: id, Name: name, Content: content})
+	}
+
+	// 按优先级去重，并记录每个被争用主机名的胜出分组
+	var resolutions []hosts.Resolution
+	if mergeMode == models.MergeModeDedupe {
+		contents, resolutions = hosts.Dedupe(sources)
+	}
+
+	// 准备新的Ghost段内容
+	var ghostContent strings.Builder
+	ghostContent.WriteString(fmt.Sprintf("\n%s\n", GhostSectionStart))
+	ghostContent.WriteString(sectionManagedNote + "\n")
+	ghostContent.WriteString(sectionPreservedNote + "\n")
+	ghostContent.WriteString(generatedAtPrefix + time.Now().Format(time.RFC3339) + "\n\n")
+
+	if len(resolutions) > 0 {
+		ghostContent.WriteString(contestedHeader + "\n")
+		for _, resolution := range resolutions {
+			ghostContent.WriteString(fmt.Sprintf("#   %s\n", resolution))
+		}
+		ghostContent.WriteString("\n")
+	}
+
+	// 添加启用的host组内容
+	for i, name := range names {
+		content := contents[i]
+		ghostContent.WriteString(groupStartMarker(ids[i], name) + "\n")
 		if content != "" {
 			ghostContent.WriteString(content)
-			ghostContent.WriteString(fmt.Sprintf("\n# End of group: %s\n\n", name))
+			ghostContent.WriteString("\n" + groupEndMarker(ids[i], name) + "\n\n")
 		} else {
-			ghostContent.WriteString(fmt.Sprintf("# End of group: %s\n\n", name))
+			ghostContent.WriteString(groupEndMarker(ids[i], name) + "\n\n")
 		}
 	}
 
@@ -153,13 +207,46 @@ func (hm *HostManager) ApplyHostGroups(hostGroups []map[string]interface{}) erro
 	// 组合最终内容，确保不产生多余空行
 	finalContent := strings.TrimRight(contentWithoutGhost, "\n") + "\n" + ghostContent.String()
 
-	// 写入系统hosts文件
-	err = hm.WriteSystemHosts(finalContent)
-	if err != nil {
-		return fmt.Errorf("failed to write updated hosts file: %w", err)
+	return finalContent, nil
+}
+
+// ExtractGhostSection 返回内容中Ghost段的文本（包括开始和结束标记行），不存在完整的Ghost段时返回false
+func ExtractGhostSection(content string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	start := -1
+	for i, line := range lines {
+		if start < 0 && strings.Contains(line, GhostSectionStart) {
+			start = i
+		} else if start >= 0 && strings.Contains(line, GhostSectionEnd) {
+			return strings.Join(lines[start:i+1], "\n"), true
+		}
 	}
+	return "", false
+}
 
-	return nil
+// IsGeneratedLine 判断Ghost段中的一行是否由Ghost自动生成（标记、说明注释等）
+// 只匹配Ghost实际写入的行，用户添加的相似注释不会被当作生成的行
+func IsGeneratedLine(line string) bool {
+	text := strings.TrimSpace(line)
+	switch text {
+	case GhostSectionStart, GhostSectionEnd, sectionManagedNote, sectionPreservedNote, contestedHeader:
+		return true
+	}
+
+	if strings.HasPrefix(text, generatedAtPrefix) {
+		_, err := time.Parse(time.RFC3339, strings.TrimPrefix(text, generatedAtPrefix))
+		return err == nil
+	}
+	if _, _, ok := parseMarker(text, groupStartPrefix); ok {
+		return true
+	}
+	if _, _, ok := parseMarker(text, groupEndPrefix); ok {
+		return true
+	}
+
+	// 去重合并时生成的争用说明，缩进是格式的一部分，因此不去除行首空白
+	match := resolutionPattern.FindStringSubmatch(strings.TrimRight(line, " \t\r"))
+	return match != nil && net.ParseIP(match[2]) != nil
 }
 
 // removeGhostEntries 从内容中移除现有的Ghost段
@@ -201,13 +288,8 @@ func (hm *HostManager) IsRunningAsAdmin() bool {
 
 // HasWritePermission 检查是否有写入系统hosts文件的权限
 func (hm *HostManager) HasWritePermission() bool {
-	// 尝试打开文件进行追加写入，以检查权限
-	file, err := os.OpenFile(hm.SystemHostPath, os.O_WRONLY|os.O_APPEND, 0)
-	if err != nil {
-		return false
-	}
-	file.Close()
-	return true
+	// 与写入时的判断一致：能替换文件或能直接写入文件即可
+	return atomicfile.CanWrite(hm.SystemHostPath)
 }
 
 // RequestElevatedPrivileges 请求提升权限以修改系统hosts文件
@@ -243,7 +325,7 @@ func (hm *HostManager) RestoreFromBackup(backupPath string) error {
 		return fmt.Errorf("failed to read backup file: %w", err)
 	}
 
-	err = os.WriteFile(hm.SystemHostPath, backupContent, 0644)
+	err = atomicfile.WriteFile(hm.SystemHostPath, backupContent, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to restore from backup: %w", err)
 	}
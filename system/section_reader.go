@@ -0,0 +1,134 @@
+package system
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"ghost/models"
+)
+
+const (
+	// groupStartPrefix 分组开始标记前缀
+	groupStartPrefix = "# Start of group: "
+	// groupEndPrefix 分组结束标记前缀
+	groupEndPrefix = "# End of group: "
+)
+
+// groupMarkerPattern 解析分组标记中的名称和ID，旧版本写入的标记不包含ID
+var groupMarkerPattern = regexp.MustCompile(`^(.*?)(?: \(id: ([0-9A-Za-z_-]+)\))?$`)
+
+// SectionGroup 从系统hosts文件Ghost段中解析出的一个分组
+type SectionGroup struct {
+	ID        string `json:"id"` // 旧版本写入的标记不含ID时为空
+	Name      string `json:"name"`
+	Content   string `json:"content"`
+	StartLine int    `json:"startLine"` // 开始标记所在行号（从1开始）
+	EndLine   int    `json:"endLine"`   // 结束标记所在行号（从1开始）
+}
+
+// groupStartMarker 生成以分组ID为键的开始标记
+func groupStartMarker(id, name string) string {
+	return groupStartPrefix + markerLabel(id, name)
+}
+
+// groupEndMarker 生成以分组ID为键的结束标记
+func groupEndMarker(id, name string) string {
+	return groupEndPrefix + markerLabel(id, name)
+}
+
+// markerLabel 生成标记中的名称和ID部分
+func markerLabel(id, name string) string {
+	// 名称中的换行会破坏标记行
+	name = strings.NewReplacer("\r", " ", "\n", " ").Replace(name)
+	if id == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (id: %s)", name, id)
+}
+
+// parseMarker 解析标记行，返回名称和ID
+func parseMarker(text, prefix string) (string, string, bool) {
+	if !strings.HasPrefix(text, prefix) {
+		return "", "", false
+	}
+	match := groupMarkerPattern.FindStringSubmatch(strings.TrimPrefix(text, prefix))
+	return match[1], match[2], true
+}
+
+// ParseGhostSection 从系统hosts文件内容中解析出Ghost段包含的各个分组
+func ParseGhostSection(content string) []SectionGroup {
+	var groups []SectionGroup
+	var current *SectionGroup
+	var body []string
+	inSection := false
+
+	for i, line := range strings.Split(content, "\n") {
+		text := strings.TrimRight(line, "\r")
+		switch {
+		case strings.Contains(text, GhostSectionStart):
+			inSection = true
+			continue
+		case strings.Contains(text, GhostSectionEnd):
+			inSection = false
+			current = nil
+			continue
+		case !inSection:
+			continue
+		}
+
+		if name, id, ok := parseMarker(text, groupStartPrefix); ok {
+			current = &SectionGroup{ID: id, Name: name, StartLine: i + 1}
+			body = nil
+			continue
+		}
+
+		if name, id, ok := parseMarker(text, groupEndPrefix); ok && current != nil {
+			// 旧版本标记以名称为键，新版本以ID为键
+			if id == current.ID && (id != "" || name == current.Name) {
+				current.EndLine = i + 1
+				current.Content = strings.Join(body, "\n")
+				groups = append(groups, *current)
+				current = nil
+				continue
+			}
+		}
+
+		if current != nil {
+			body = append(body, line)
+		}
+	}
+
+	return groups
+}
+
+// AttributeLines 标注系统hosts文件中每一行的来源分组
+func AttributeLines(content string) []models.LineOrigin {
+	lines := strings.Split(content, "\n")
+	origins := make([]models.LineOrigin, len(lines))
+	for i, line := range lines {
+		origins[i] = models.LineOrigin{Line: i + 1, Text: line}
+	}
+
+	// 标记Ghost段内的行
+	inSection := false
+	for i, line := range lines {
+		if strings.Contains(line, GhostSectionStart) {
+			inSection = true
+		}
+		origins[i].Managed = inSection
+		if strings.Contains(line, GhostSectionEnd) {
+			inSection = false
+		}
+	}
+
+	// 标记属于各分组的行（不含标记行本身）
+	for _, group := range ParseGhostSection(content) {
+		for n := group.StartLine + 1; n < group.EndLine; n++ {
+			origins[n-1].GroupID = group.ID
+			origins[n-1].GroupName = group.Name
+		}
+	}
+
+	return origins
+}
@@ -0,0 +1,178 @@
+package system
+
+import (
+	"strings"
+	"testing"
+
+	"ghost/models"
+)
+
+// TestParseGhostSection 测试从Ghost段中解析分组，包括旧版本的标记和不完整的分组
+func TestParseGhostSection(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []SectionGroup
+	}{
+		{
+			name:    "no ghost section",
+			content: "127.0.0.1 localhost\n# Start of group: a (id: 1)\n10.0.0.1 a.local\n# End of group: a (id: 1)\n",
+		},
+		{
+			name: "groups keyed by ID",
+			content: "127.0.0.1 localhost\n" +
+				GhostSectionStart + "\n" +
+				"# Start of group: Dev (id: 1)\n" +
+				"10.0.0.1 dev.local\n" +
+				"10.0.0.2 api.local\n" +
+				"# End of group: Dev (id: 1)\n" +
+				"\n" +
+				"# Start of group: Empty (id: 2)\n" +
+				"# End of group: Empty (id: 2)\n" +
+				GhostSectionEnd + "\n",
+			want: []SectionGroup{
+				{ID: "1", Name: "Dev", Content: "10.0.0.1 dev.local\n10.0.0.2 api.local", StartLine: 3, EndLine: 6},
+				{ID: "2", Name: "Empty", Content: "", StartLine: 8, EndLine: 9},
+			},
+		},
+		{
+			name: "legacy markers keyed by name",
+			content: GhostSectionStart + "\n" +
+				"# Start of group: Old\n" +
+				"10.0.0.1 old.local\n" +
+				"# End of group: Old\n" +
+				GhostSectionEnd,
+			want: []SectionGroup{
+				{Name: "Old", Content: "10.0.0.1 old.local", StartLine: 2, EndLine: 4},
+			},
+		},
+		{
+			name: "name containing parentheses",
+			content: GhostSectionStart + "\n" +
+				"# Start of group: Work (VPN) (id: abc-1)\n" +
+				"10.0.0.1 vpn.local\n" +
+				"# End of group: Work (VPN) (id: abc-1)\n" +
+				GhostSectionEnd,
+			want: []SectionGroup{
+				{ID: "abc-1", Name: "Work (VPN)", Content: "10.0.0.1 vpn.local", StartLine: 2, EndLine: 4},
+			},
+		},
+		{
+			name: "mismatched end marker stays in the body",
+			content: GhostSectionStart + "\n" +
+				"# Start of group: A (id: 1)\n" +
+				"# End of group: B (id: 2)\n" +
+				"# End of group: A (id: 1)\n" +
+				GhostSectionEnd,
+			want: []SectionGroup{
+				{ID: "1", Name: "A", Content: "# End of group: B (id: 2)", StartLine: 2, EndLine: 4},
+			},
+		},
+		{
+			name: "unterminated group is dropped",
+			content: GhostSectionStart + "\n" +
+				"# Start of group: A (id: 1)\n" +
+				"10.0.0.1 a.local\n" +
+				GhostSectionEnd,
+		},
+		{
+			name: "CRLF line endings",
+			content: GhostSectionStart + "\r\n" +
+				"# Start of group: A (id: 1)\r\n" +
+				"10.0.0.1 a.local\r\n" +
+				"# End of group: A (id: 1)\r\n" +
+				GhostSectionEnd + "\r\n",
+			want: []SectionGroup{
+				{ID: "1", Name: "A", Content: "10.0.0.1 a.local\r", StartLine: 2, EndLine: 4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		groups := ParseGhostSection(tt.content)
+		if len(groups) != len(tt.want) {
+			t.Errorf("%s: expected %d groups, got %+v", tt.name, len(tt.want), groups)
+			continue
+		}
+		for i := range groups {
+			if groups[i] != tt.want[i] {
+				t.Errorf("%s: group %d: expected %+v, got %+v", tt.name, i, tt.want[i], groups[i])
+			}
+		}
+	}
+}
+
+// TestRenderHostGroupsMergeMode 测试两种合并方式：concat保留所有分组的内容，dedupe按优先级去掉被争用的映射
+func TestRenderHostGroupsMergeMode(t *testing.T) {
+	groups := []map[string]interface{}{
+		{"id": "1", "name": "High", "enabled": true, "content": "10.0.0.1 api.local"},
+		{"id": "2", "name": "Low", "enabled": true, "content": "10.0.0.2 api.local\n10.0.0.3 web.local"},
+		{"id": "3", "name": "Off", "enabled": false, "content": "10.0.0.4 api.local"},
+	}
+
+	tests := []struct {
+		mode string
+		want map[string]string // 分组ID -> 应用后的内容
+		note bool              // 是否生成争用说明
+	}{
+		{models.MergeModeConcat, map[string]string{"1": "10.0.0.1 api.local", "2": "10.0.0.2 api.local\n10.0.0.3 web.local"}, false},
+		{"", map[string]string{"1": "10.0.0.1 api.local", "2": "10.0.0.2 api.local\n10.0.0.3 web.local"}, false},
+		{models.MergeModeDedupe, map[string]string{"1": "10.0.0.1 api.local", "2": "10.0.0.3 web.local"}, true},
+	}
+
+	hm := &HostManager{}
+	for _, tt := range tests {
+		rendered, err := hm.RenderHostGroups("127.0.0.1 localhost\n", groups, tt.mode)
+		if err != nil {
+			t.Fatalf("mode %q: %v", tt.mode, err)
+		}
+		if !strings.HasPrefix(rendered, "127.0.0.1 localhost\n") {
+			t.Errorf("mode %q: system entries were not preserved:\n%s", tt.mode, rendered)
+		}
+
+		sections := ParseGhostSection(rendered)
+		if len(sections) != len(tt.want) {
+			t.Fatalf("mode %q: expected %d groups, got %+v", tt.mode, len(tt.want), sections)
+		}
+		for _, section := range sections {
+			if section.Content != tt.want[section.ID] {
+				t.Errorf("mode %q: group %s: expected %q, got %q", tt.mode, section.ID, tt.want[section.ID], section.Content)
+			}
+		}
+		if strings.Contains(rendered, "# Contested hostnames") != tt.note {
+			t.Errorf("mode %q: expected contested note=%t:\n%s", tt.mode, tt.note, rendered)
+		}
+	}
+}
+
+// TestIsGeneratedLine 测试只有Ghost实际写入的行被识别为生成的行，用户添加的相似注释不受影响
+func TestIsGeneratedLine(t *testing.T) {
+	groups := []map[string]interface{}{
+		{"id": "1", "name": "High", "enabled": true, "content": "10.0.0.1 api.local"},
+		{"id": "2", "name": "Low", "enabled": true, "content": "10.0.0.2 api.local"},
+	}
+	rendered, err := (&HostManager{}).RenderHostGroups("", groups, models.MergeModeDedupe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	section, _ := ExtractGhostSection(rendered)
+	for _, line := range strings.Split(section, "\n") {
+		if strings.HasPrefix(line, "#") && !IsGeneratedLine(line) {
+			t.Errorf("rendered line not recognized as generated: %q", line)
+		}
+	}
+
+	userLines := []string{
+		"#   staging -> use 10.0.0.5 until Friday",
+		"#   api.local -> 10.0.0.9",
+		"# Generated at: by hand",
+		"# This section is managed by Ghost, do not trust it",
+		"# Contested hostnames are a pain",
+		"10.0.0.1 api.local",
+	}
+	for _, line := range userLines {
+		if IsGeneratedLine(line) {
+			t.Errorf("user line recognized as generated: %q", line)
+		}
+	}
+}